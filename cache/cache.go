@@ -2,10 +2,15 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/safedep/dry/log"
+	"golang.org/x/sync/singleflight"
 )
 
 // Type for cache data
@@ -22,21 +27,113 @@ type CacheKey struct {
 // Defines a type for a cacheable function (closure)
 type CachableFunc[T any] func() (T, error)
 
+// Defines a type for a context aware cacheable function (closure)
+type CachableContextFunc[T any] func(ctx context.Context) (T, error)
+
+// ErrCacheMiss is returned (possibly wrapped) by CacheV2 implementations
+// when a key is not available in the cache or the entry has expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// Coalesces concurrent loads of the same key in Through
+var throughFlight singleflight.Group
+
+var errInvalidationFilterNoSource = errors.New("invalidation filter must have a source")
+
+// InvalidationFilter selects cache entries for invalidation. Source is
+// mandatory. An empty Type matches all types within the Source and an
+// empty IdPrefix matches all ids
+type InvalidationFilter struct {
+	Source   string
+	Type     string
+	IdPrefix string
+}
+
+// Validate checks if the filter can be used for invalidation
+func (f InvalidationFilter) Validate() error {
+	if f.Source == "" {
+		return errInvalidationFilterNoSource
+	}
+
+	return nil
+}
+
+// Matches returns true if key is selected by the filter
+func (f InvalidationFilter) Matches(key *CacheKey) bool {
+	if key == nil || key.Source != f.Source {
+		return false
+	}
+
+	if f.Type != "" && key.Type != f.Type {
+		return false
+	}
+
+	return strings.HasPrefix(key.Id, f.IdPrefix)
+}
+
 // Internally maintained default caching adapter
 var globalCachingAdapter Cache
 
+// Context aware view of the global caching adapter. Kept stable across
+// calls so that concurrent misses on the global adapter are coalesced
+var globalCachingAdapterV2 CacheV2
+
 // Cache define the contract for implementing caches
 type Cache interface {
 	Put(key *CacheKey, data *CacheData, ttl time.Duration) error
 	Get(key *CacheKey) (*CacheData, error)
 }
 
+// CacheV2 define the context aware contract for implementing caches
+// along with support for deleting and invalidating entries
+type CacheV2 interface {
+	PutContext(ctx context.Context, key *CacheKey, data *CacheData, ttl time.Duration) error
+
+	// GetContext returns an error wrapping ErrCacheMiss when the key is
+	// not found or the entry has expired
+	GetContext(ctx context.Context, key *CacheKey) (*CacheData, error)
+
+	// Delete removes the entry for key. Deleting a non-existent key
+	// is not an error
+	Delete(ctx context.Context, key *CacheKey) error
+
+	// Invalidate removes all entries selected by the filter
+	Invalidate(ctx context.Context, filter InvalidationFilter) error
+}
+
+// ExpiryAwareCache is implemented by caches that can report when an
+// entry expires. A zero expiry means the entry never expires
+type ExpiryAwareCache interface {
+	GetWithExpiry(ctx context.Context, key *CacheKey) (*CacheData, time.Time, error)
+}
+
+// CacheAdapter is a cache implementing both the legacy and the
+// context aware contract
+type CacheAdapter interface {
+	Cache
+	CacheV2
+}
+
+// ClosableCache is a cache that owns background resources, such as
+// a janitor goroutine, which must be released by calling Close
+type ClosableCache interface {
+	CacheAdapter
+
+	Close() error
+}
+
 func init() {
 	Disable()
 }
 
 func setGlobalCachingAdapter(a Cache) {
 	globalCachingAdapter = a
+
+	v2, ok := a.(CacheV2)
+	if !ok {
+		v2 = &legacyCache{cache: a}
+	}
+
+	globalCachingAdapterV2 = v2
 }
 
 func Disable() {
@@ -47,41 +144,214 @@ func EnableWith(adapter Cache) {
 	setGlobalCachingAdapter(adapter)
 }
 
-// Through define the devex sugar - Read through cache
-func Through[T any](key *CacheKey, ttl time.Duration, fun CachableFunc[T]) (T, error) {
-	system := globalCachingAdapter
+// Through define the devex sugar - Read through cache using the
+// global caching adapter
+func Through[T any](key *CacheKey, ttl time.Duration, fun CachableFunc[T],
+	opts ...ThroughOption) (T, error) {
+	system := globalCachingAdapterV2
 	if system == nil {
 		panic("default cache adapter is not set")
 	}
 
+	return ThroughContext(context.Background(), system, key, ttl,
+		func(_ context.Context) (T, error) {
+			return fun()
+		}, opts...)
+}
+
+// ThroughContext is a read through cache bound to a cache instance
+// instead of the global caching adapter. Concurrent misses for the same
+// key on the same cache instance are collapsed into a single invocation
+// of fun whose result is shared by all callers
+func ThroughContext[T any](ctx context.Context, system CacheV2, key *CacheKey,
+	ttl time.Duration, fun CachableContextFunc[T], opts ...ThroughOption) (T, error) {
+	config := newThroughConfig(opts)
+
 	var empty T
-	data, err := system.Get(key)
-	if err != nil {
-		// Cache lookup failed, invoke actual function
-		realData, err := fun()
-		if err != nil {
-			return empty, err
-		}
+	flightKey := fmt.Sprintf("%p/%T/%s", system, empty, cacheKeyString(key))
+
+	start := time.Now()
+	result := metricResultMiss
+
+	data, err := system.GetContext(ctx, key)
+	if err == nil {
+		// Cache lookup is successful
+		// Adapter bug in case of NPE on data
+		entry := decodeCacheEntry(*data)
+
+		switch {
+		case entry.negative && config.notFoundErr != nil:
+			observeThrough(ctx, key, metricResultNegative, start)
+			return empty, config.notFoundErr
+		case entry.negative:
+			// Negative caching is not enabled for this call, treat as miss
+		case !entry.stale(time.Now()):
+			value, err := throughDecode[T](&config, &entry)
+			if err != nil {
+				observeSerializationError(key, "decode")
+				observeThrough(ctx, key, metricResultError, start)
+				return empty, err
+			}
+
+			observeThrough(ctx, key, metricResultHit, start)
+			return value, nil
+		case config.staleTTL > 0:
+			value, err := throughDecode[T](&config, &entry)
+			if err != nil {
+				observeSerializationError(key, "decode")
+				observeThrough(ctx, key, metricResultError, start)
+				return empty, err
+			}
 
-		// Cache output from actual function - Must not fail original path
-		serializedData, err := JsonSerialize(realData)
-		if err != nil {
-			log.Debugf("Cache: Failed to serialize type:%T err:%v",
-				realData, err)
-			return realData, nil
+			// Serve the stale value and refresh in background, detached from
+			// the caller's cancellation. DoChan coalesces with in-flight loads
+			throughFlight.DoChan(flightKey, func() (any, error) {
+				return throughLoad(context.WithoutCancel(ctx), system, key, ttl, fun, config)
+			})
+
+			observeThrough(ctx, key, metricResultStale, start)
+			return value, nil
 		}
+	} else if !errors.Is(err, ErrCacheMiss) {
+		log.Debugf("Cache: Failed to get due to: %v", err)
+		result = metricResultError
+	}
+
+	// Cache lookup failed, invoke actual function. The shared load is
+	// detached from the caller's cancellation so that a cancelled caller
+	// does not fail the others waiting on it, each caller waits on its own ctx
+	ch := throughFlight.DoChan(flightKey, func() (any, error) {
+		return throughLoad(context.WithoutCancel(ctx), system, key, ttl, fun, config)
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		observeThrough(ctx, key, metricResultError, start)
+		return empty, ctx.Err()
+	}
+
+	observeThrough(ctx, key, result, start)
+	if res.Err != nil {
+		return empty, res.Err
+	}
+
+	// A nil interface value is boxed into a nil any
+	value, _ := res.Val.(T)
+	return value, nil
+}
 
-		err = system.Put(key, &serializedData, ttl)
-		if err != nil {
-			log.Debugf("Cache: Failed to put due to: %v", err)
+// throughLoad invokes the actual function and caches its output
+func throughLoad[T any](ctx context.Context, system CacheV2, key *CacheKey,
+	ttl time.Duration, fun CachableContextFunc[T], config throughConfig) (T, error) {
+	var empty T
+	realData, err := fun(ctx)
+	if err != nil {
+		if config.notFoundErr != nil && errors.Is(err, config.notFoundErr) {
+			negativeData := encodeCacheEntry(cacheEntry{negative: true})
+			if perr := system.PutContext(ctx, key, &negativeData, config.negativeTTL); perr != nil {
+				log.Debugf("Cache: Failed to put negative entry due to: %v", perr)
+			}
 		}
 
+		return empty, err
+	}
+
+	// Cache output from actual function - Must not fail original path
+	serializedData, err := throughEncode(&config, realData, ttl)
+	if err != nil {
+		observeSerializationError(key, "encode")
+		log.Debugf("Cache: Failed to serialize type:%T err:%v",
+			realData, err)
 		return realData, nil
-	} else {
-		// Cache lookup is successful
-		// Adapter bug in case of NPE on data
-		return JsonDeserialize[T](*data)
 	}
+
+	// Entries eligible for stale-while-revalidate are kept beyond their
+	// ttl and carry their freshness deadline in the entry header
+	storeTTL := ttl
+	if config.staleTTL > 0 {
+		storeTTL = ttl + config.staleTTL
+	}
+
+	err = system.PutContext(ctx, key, &serializedData, storeTTL)
+	if err != nil {
+		log.Debugf("Cache: Failed to put due to: %v", err)
+	}
+
+	return realData, nil
+}
+
+// throughEncode serializes value into the representation stored in cache.
+// Plain JSON is written when no header is required so that the entry stays
+// readable by older versions
+func throughEncode[T any](config *throughConfig, value T, ttl time.Duration) (CacheData, error) {
+	serialized, err := config.codec.Marshal(value)
+	if err != nil {
+		return CacheData{}, err
+	}
+
+	if !config.requiresHeader() {
+		return CacheData(serialized), nil
+	}
+
+	compressed, err := compress(config.compression, serialized)
+	if err != nil {
+		return CacheData{}, err
+	}
+
+	entry := cacheEntry{
+		codec:       config.codec.ID(),
+		compression: config.compression,
+		payload:     compressed,
+	}
+
+	if config.staleTTL > 0 {
+		entry.freshUntil = time.Now().Add(ttl)
+	}
+
+	return encodeCacheEntry(entry), nil
+}
+
+func throughDecode[T any](config *throughConfig, entry *cacheEntry) (T, error) {
+	var value T
+
+	codec, err := config.codecFor(entry.codec)
+	if err != nil {
+		return value, err
+	}
+
+	serialized, err := decompress(entry.compression, entry.payload)
+	if err != nil {
+		return value, err
+	}
+
+	err = codec.Unmarshal(serialized, &value)
+	return value, err
+}
+
+var errLegacyCacheUnsupported = errors.New("operation not supported by legacy cache")
+
+// legacyCache adapts a Cache to the CacheV2 contract. The context is
+// ignored and deletion is not supported
+type legacyCache struct {
+	cache Cache
+}
+
+func (c *legacyCache) PutContext(_ context.Context, key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.cache.Put(key, data, ttl)
+}
+
+func (c *legacyCache) GetContext(_ context.Context, key *CacheKey) (*CacheData, error) {
+	return c.cache.Get(key)
+}
+
+func (c *legacyCache) Delete(_ context.Context, _ *CacheKey) error {
+	return errLegacyCacheUnsupported
+}
+
+func (c *legacyCache) Invalidate(_ context.Context, _ InvalidationFilter) error {
+	return errLegacyCacheUnsupported
 }
 
 func JsonSerialize[T any](data T) (CacheData, error) {
@@ -99,3 +369,7 @@ func JsonDeserialize[T any](data CacheData) (T, error) {
 
 	return deserialized, err
 }
+
+func cacheKeyString(key *CacheKey) string {
+	return key.Source + "/" + key.Type + "/" + key.Id
+}
@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCacheValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestInvalidationFilter(t *testing.T) {
+	cases := []struct {
+		name    string
+		filter  InvalidationFilter
+		key     *CacheKey
+		matches bool
+	}{
+		{
+			"Source only matches all types",
+			InvalidationFilter{Source: "npm"},
+			&CacheKey{Source: "npm", Type: "package", Id: "express"},
+			true,
+		},
+		{
+			"Source mismatch",
+			InvalidationFilter{Source: "npm"},
+			&CacheKey{Source: "pypi", Type: "package", Id: "express"},
+			false,
+		},
+		{
+			"Type mismatch",
+			InvalidationFilter{Source: "npm", Type: "version"},
+			&CacheKey{Source: "npm", Type: "package", Id: "express"},
+			false,
+		},
+		{
+			"Id prefix match",
+			InvalidationFilter{Source: "npm", Type: "package", IdPrefix: "@angular/"},
+			&CacheKey{Source: "npm", Type: "package", Id: "@angular/core"},
+			true,
+		},
+		{
+			"Id prefix mismatch",
+			InvalidationFilter{Source: "npm", Type: "package", IdPrefix: "@angular/"},
+			&CacheKey{Source: "npm", Type: "package", Id: "express"},
+			false,
+		},
+		{
+			"Nil key",
+			InvalidationFilter{Source: "npm"},
+			nil,
+			false,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.matches, test.filter.Matches(test.key))
+		})
+	}
+
+	assert.ErrorIs(t, InvalidationFilter{}.Validate(), errInvalidationFilterNoSource)
+}
+
+func TestThroughContext(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	ctx := context.Background()
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	calls := 0
+	fun := func(_ context.Context) (testCacheValue, error) {
+		calls++
+		return testCacheValue{Name: "express", Count: calls}, nil
+	}
+
+	res, err := ThroughContext(ctx, c, key, time.Minute, fun)
+	require.NoError(t, err)
+	assert.Equal(t, testCacheValue{Name: "express", Count: 1}, res)
+
+	res, err = ThroughContext(ctx, c, key, time.Minute, fun)
+	require.NoError(t, err)
+	assert.Equal(t, testCacheValue{Name: "express", Count: 1}, res)
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, c.Delete(ctx, key))
+
+	res, err = ThroughContext(ctx, c, key, time.Minute, fun)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Count)
+}
+
+func TestThroughContextErrorIsNotCached(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	_, err := ThroughContext(context.Background(), c, key, time.Minute,
+		func(_ context.Context) (testCacheValue, error) {
+			return testCacheValue{}, errors.New("upstream failure")
+		})
+
+	assert.ErrorContains(t, err, "upstream failure")
+
+	_, err = c.GetContext(context.Background(), key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestThroughUsesGlobalAdapter(t *testing.T) {
+	t.Cleanup(Disable)
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	calls := 0
+	fun := func() (testCacheValue, error) {
+		calls++
+		return testCacheValue{Name: "express"}, nil
+	}
+
+	// Caching is disabled by default
+	for i := 0; i < 2; i++ {
+		_, err := Through(key, time.Minute, fun)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, calls)
+
+	EnableWith(NewUnsafeMemoryCache())
+
+	for i := 0; i < 2; i++ {
+		res, err := Through(key, time.Minute, fun)
+		require.NoError(t, err)
+		assert.Equal(t, "express", res.Name)
+	}
+
+	assert.Equal(t, 3, calls)
+}
+
+func TestCacheAdaptersInvalidate(t *testing.T) {
+	adapters := map[string]CacheAdapter{
+		"unsafe memory":  NewUnsafeMemoryCache(),
+		"bounded memory": newTestMemoryCache(t, MemoryCacheConfig{}),
+	}
+
+	for name, c := range adapters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			data := CacheData("value")
+
+			keys := []*CacheKey{
+				{Source: "npm", Type: "package", Id: "@angular/core"},
+				{Source: "npm", Type: "package", Id: "@angular/common"},
+				{Source: "npm", Type: "package", Id: "express"},
+				{Source: "npm", Type: "version", Id: "express"},
+				{Source: "pypi", Type: "package", Id: "requests"},
+			}
+
+			for _, key := range keys {
+				require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+			}
+
+			assert.Error(t, c.Invalidate(ctx, InvalidationFilter{}))
+
+			require.NoError(t, c.Invalidate(ctx, InvalidationFilter{
+				Source:   "npm",
+				Type:     "package",
+				IdPrefix: "@angular/",
+			}))
+
+			for i, key := range keys {
+				_, err := c.GetContext(ctx, key)
+				if i < 2 {
+					assert.ErrorIs(t, err, ErrCacheMiss)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			require.NoError(t, c.Invalidate(ctx, InvalidationFilter{Source: "npm"}))
+
+			_, err := c.GetContext(ctx, keys[3])
+			assert.ErrorIs(t, err, ErrCacheMiss)
+
+			_, err = c.GetContext(ctx, keys[4])
+			assert.NoError(t, err)
+
+			require.NoError(t, c.Delete(ctx, keys[4]))
+			require.NoError(t, c.Delete(ctx, keys[4]))
+
+			_, err = c.GetContext(ctx, keys[4])
+			assert.ErrorIs(t, err, ErrCacheMiss)
+		})
+	}
+}
+
+func TestThroughContextCoalescesConcurrentMisses(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fun := func(_ context.Context) (testCacheValue, error) {
+		calls.Add(1)
+		<-release
+		return testCacheValue{Name: "express"}, nil
+	}
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	results := make(chan testCacheValue, callers)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := ThroughContext(context.Background(), c, key, time.Minute, fun)
+			assert.NoError(t, err)
+			results <- res
+		}()
+	}
+
+	// Give all callers a chance to join the in-flight load
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	wg.Wait()
+	close(results)
+
+	assert.Equal(t, int32(1), calls.Load())
+	for res := range results {
+		assert.Equal(t, "express", res.Name)
+	}
+}
+
+func TestThroughContextCancelledCallerDoesNotFailWaiters(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fun := func(ctx context.Context) (testCacheValue, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+			return testCacheValue{Name: "express"}, nil
+		case <-ctx.Done():
+			return testCacheValue{}, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := ThroughContext(ctx, c, key, time.Minute, fun)
+		first <- err
+	}()
+
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	second := make(chan testCacheValue, 1)
+	go func() {
+		res, err := ThroughContext(context.Background(), c, key, time.Minute, fun)
+		assert.NoError(t, err)
+		second <- res
+	}()
+
+	// The first caller gives up without failing the shared load
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "express", (<-second).Name)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestThroughContextNilInterfaceValue(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "nil"}
+
+	fun := func(_ context.Context) (fmt.Stringer, error) {
+		return nil, nil
+	}
+
+	res, err := ThroughContext(context.Background(), c, key, time.Minute, fun)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestThroughContextStaleWhileRevalidate(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	var calls atomic.Int32
+	fun := func(_ context.Context) (testCacheValue, error) {
+		n := calls.Add(1)
+		return testCacheValue{Name: "express", Count: int(n)}, nil
+	}
+
+	ttl := 50 * time.Millisecond
+	opt := WithStaleWhileRevalidate(time.Minute)
+
+	res, err := ThroughContext(context.Background(), c, key, ttl, fun, opt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Count)
+
+	time.Sleep(2 * ttl)
+
+	// Stale value is served while a refresh happens in background
+	res, err = ThroughContext(context.Background(), c, key, ttl, fun, opt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Count)
+
+	assert.Eventually(t, func() bool {
+		res, err := ThroughContext(context.Background(), c, key, time.Minute, fun, opt)
+		return err == nil && res.Count == 2
+	}, time.Second, time.Millisecond)
+
+	// Without the option a stale entry is treated as a miss
+	time.Sleep(2 * ttl)
+	res, err = ThroughContext(context.Background(), c, key, ttl, fun)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 3, res.Count)
+}
+
+func TestThroughContextNegativeCaching(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "does-not-exist"}
+
+	errNotFound := errors.New("package not found")
+
+	calls := 0
+	fun := func(_ context.Context) (testCacheValue, error) {
+		calls++
+		return testCacheValue{}, fmt.Errorf("registry: %w", errNotFound)
+	}
+
+	opt := WithNegativeCaching(errNotFound, 20*time.Millisecond)
+
+	_, err := ThroughContext(context.Background(), c, key, time.Minute, fun, opt)
+	assert.ErrorIs(t, err, errNotFound)
+
+	_, err = ThroughContext(context.Background(), c, key, time.Minute, fun, opt)
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, 1, calls)
+
+	// Negative entries are ignored when negative caching is not requested
+	_, err = ThroughContext(context.Background(), c, key, time.Minute, fun)
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, 2, calls)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, err = ThroughContext(context.Background(), c, key, time.Minute, fun, opt)
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, 3, calls)
+
+	// Other errors are never cached
+	other := func(_ context.Context) (testCacheValue, error) {
+		calls++
+		return testCacheValue{}, errors.New("timeout")
+	}
+
+	otherKey := &CacheKey{Source: "npm", Type: "package", Id: "flaky"}
+	for i := 0; i < 2; i++ {
+		_, err = ThroughContext(context.Background(), c, otherKey, time.Minute, other, opt)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, 5, calls)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// CodecID identifies the codec used to serialize a cached value. It is
+// recorded in the entry header so that entries can be decoded irrespective
+// of the codec configured by the reader
+type CodecID uint8
+
+const (
+	CodecIdJson  CodecID = 0
+	CodecIdProto CodecID = 1
+	CodecIdGob   CodecID = 2
+)
+
+// Compression identifies the compression applied to a serialized value
+type Compression uint8
+
+const (
+	CompressionNone Compression = 0
+	CompressionGzip Compression = 1
+	CompressionZstd Compression = 2
+)
+
+var (
+	errCodecUnknown       = errors.New("unknown cache codec")
+	errCodecNotProto      = errors.New("value is not a proto.Message")
+	errCompressionUnknown = errors.New("unknown cache compression")
+)
+
+// Codec define the contract for serializing values stored in cache
+type Codec interface {
+	ID() CodecID
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes data into v which is always a pointer
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+// JsonCodec returns a codec using encoding/json. This is the default
+func JsonCodec() Codec {
+	return jsonCodec{}
+}
+
+func (jsonCodec) ID() CodecID {
+	return CodecIdJson
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+type protoCodec struct{}
+
+// ProtoCodec returns a codec using protobuf binary encoding. The cached
+// type must be a proto.Message, such as a pointer to a generated struct
+func ProtoCodec() Codec {
+	return protoCodec{}
+}
+
+func (protoCodec) ID() CodecID {
+	return CodecIdProto
+}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", errCodecNotProto, v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	// v is usually a pointer to a nil message pointer, allocate the
+	// message before decoding into it
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() ||
+		rv.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("%w: %T", errCodecNotProto, v)
+	}
+
+	target := rv.Elem()
+	if target.IsNil() {
+		target.Set(reflect.New(target.Type().Elem()))
+	}
+
+	msg, ok := target.Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", errCodecNotProto, v)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+type gobCodec struct{}
+
+// GobCodec returns a codec using encoding/gob
+func GobCodec() Codec {
+	return gobCodec{}
+}
+
+func (gobCodec) ID() CodecID {
+	return CodecIdGob
+}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func codecByID(id CodecID) (Codec, error) {
+	switch id {
+	case CodecIdJson:
+		return jsonCodec{}, nil
+	case CodecIdProto:
+		return protoCodec{}, nil
+	case CodecIdGob:
+		return gobCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", errCodecUnknown, id)
+	}
+}
+
+// zstd encoders and decoders are expensive to create but safe for
+// concurrent use through EncodeAll and DecodeAll
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil)
+	})
+
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil)
+	})
+)
+
+func compress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return enc.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", errCompressionUnknown, c)
+	}
+}
+
+func decompress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() { _ = r.Close() }()
+		return io.ReadAll(r)
+	case CompressionZstd:
+		dec, err := zstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return dec.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("%w: %d", errCompressionUnknown, c)
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	value := testCacheValue{Name: "express", Count: 42}
+
+	codecs := map[string]Codec{
+		"json": JsonCodec(),
+		"gob":  GobCodec(),
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Marshal(value)
+			require.NoError(t, err)
+
+			var decoded testCacheValue
+			require.NoError(t, codec.Unmarshal(data, &decoded))
+			assert.Equal(t, value, decoded)
+		})
+	}
+}
+
+func TestProtoCodec(t *testing.T) {
+	codec := ProtoCodec()
+	msg := wrapperspb.String("express")
+
+	data, err := codec.Marshal(msg)
+	require.NoError(t, err)
+
+	// Decoding into a pointer to a nil message allocates it
+	var decoded *wrapperspb.StringValue
+	require.NoError(t, codec.Unmarshal(data, &decoded))
+	assert.True(t, proto.Equal(msg, decoded))
+
+	// Decoding into an allocated message
+	allocated := &wrapperspb.StringValue{}
+	require.NoError(t, codec.Unmarshal(data, allocated))
+	assert.True(t, proto.Equal(msg, allocated))
+
+	_, err = codec.Marshal(testCacheValue{})
+	assert.ErrorIs(t, err, errCodecNotProto)
+
+	var notProto testCacheValue
+	assert.ErrorIs(t, codec.Unmarshal(data, &notProto), errCodecNotProto)
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"name":"express"}`), 100)
+
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		compressed, err := compress(c, data)
+		require.NoError(t, err)
+
+		if c != CompressionNone {
+			assert.Less(t, len(compressed), len(data))
+		}
+
+		decompressed, err := decompress(c, compressed)
+		require.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	_, err := compress(Compression(99), data)
+	assert.ErrorIs(t, err, errCompressionUnknown)
+}
+
+func TestThroughContextWithCodecAndCompression(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	calls := 0
+	fun := func(_ context.Context) (*wrapperspb.StringValue, error) {
+		calls++
+		return wrapperspb.String("express"), nil
+	}
+
+	opts := []ThroughOption{WithCodec(ProtoCodec()), WithCompression(CompressionZstd)}
+	for i := 0; i < 2; i++ {
+		res, err := ThroughContext(ctx, c, key, time.Minute, fun, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, "express", res.GetValue())
+	}
+
+	assert.Equal(t, 1, calls)
+
+	data, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+
+	entry := decodeCacheEntry(*data)
+	assert.Equal(t, CodecIdProto, entry.codec)
+	assert.Equal(t, CompressionZstd, entry.compression)
+
+	// The entry header drives decoding irrespective of the reader's options
+	res, err := ThroughContext(ctx, c, key, time.Minute, fun)
+	require.NoError(t, err)
+	assert.Equal(t, "express", res.GetValue())
+	assert.Equal(t, 1, calls)
+}
+
+func TestThroughContextReadsLegacyJsonEntries(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	legacy := CacheData(`{"name":"express","count":7}`)
+	require.NoError(t, c.PutContext(ctx, key, &legacy, time.Minute))
+
+	res, err := ThroughContext(ctx, c, key, time.Minute,
+		func(_ context.Context) (testCacheValue, error) {
+			t.Fatal("must be served from cache")
+			return testCacheValue{}, nil
+		}, WithCompression(CompressionGzip))
+
+	require.NoError(t, err)
+	assert.Equal(t, testCacheValue{Name: "express", Count: 7}, res)
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Cache entries written by Through may carry a small header describing
+// the entry. Data without the header is treated as a plain, always fresh,
+// JSON serialized value so that entries written by older versions remain
+// readable
+//
+// Version 1
+//
+//	+-------+---------+-------+-------------------------+---------+
+//	| magic | version | flags | fresh until (unix nano) | payload |
+//	+-------+---------+-------+-------------------------+---------+
+//	   1B       1B       1B             8B
+//
+// Version 2
+//
+//	+-------+---------+-------+-------+-------------+-------------+---------+
+//	| magic | version | flags | codec | compression | fresh until | payload |
+//	+-------+---------+-------+-------+-------------+-------------+---------+
+//	   1B       1B       1B      1B         1B            8B
+const (
+	cacheEntryMagic = 0xDC
+
+	cacheEntryVersion1           = 0x01
+	cacheEntryVersion1HeaderSize = 11
+
+	cacheEntryVersion2           = 0x02
+	cacheEntryVersion2HeaderSize = 13
+
+	cacheEntryFlagNegative = 1 << 0
+)
+
+type cacheEntry struct {
+	// Entry records a negative (not found) result
+	negative bool
+
+	// Zero when the entry does not track freshness
+	freshUntil time.Time
+
+	codec       CodecID
+	compression Compression
+
+	// Serialized and compressed value
+	payload CacheData
+}
+
+func (e *cacheEntry) stale(now time.Time) bool {
+	return !e.freshUntil.IsZero() && !now.Before(e.freshUntil)
+}
+
+// encodeCacheEntry always writes the latest header version
+func encodeCacheEntry(e cacheEntry) CacheData {
+	data := make(CacheData, cacheEntryVersion2HeaderSize,
+		cacheEntryVersion2HeaderSize+len(e.payload))
+
+	data[0] = cacheEntryMagic
+	data[1] = cacheEntryVersion2
+
+	if e.negative {
+		data[2] |= cacheEntryFlagNegative
+	}
+
+	data[3] = byte(e.codec)
+	data[4] = byte(e.compression)
+
+	if !e.freshUntil.IsZero() {
+		binary.BigEndian.PutUint64(data[5:13], uint64(e.freshUntil.UnixNano()))
+	}
+
+	return append(data, e.payload...)
+}
+
+func decodeCacheEntry(data CacheData) cacheEntry {
+	if len(data) < 2 || data[0] != cacheEntryMagic {
+		return cacheEntry{payload: data}
+	}
+
+	var entry cacheEntry
+	var freshUntil uint64
+
+	switch {
+	case data[1] == cacheEntryVersion1 && len(data) >= cacheEntryVersion1HeaderSize:
+		freshUntil = binary.BigEndian.Uint64(data[3:11])
+		entry.payload = data[cacheEntryVersion1HeaderSize:]
+	case data[1] == cacheEntryVersion2 && len(data) >= cacheEntryVersion2HeaderSize:
+		entry.codec = CodecID(data[3])
+		entry.compression = Compression(data[4])
+
+		freshUntil = binary.BigEndian.Uint64(data[5:13])
+		entry.payload = data[cacheEntryVersion2HeaderSize:]
+	default:
+		return cacheEntry{payload: data}
+	}
+
+	entry.negative = data[2]&cacheEntryFlagNegative != 0
+	if freshUntil != 0 {
+		entry.freshUntil = time.Unix(0, int64(freshUntil))
+	}
+
+	return entry
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEntryEncoding(t *testing.T) {
+	freshUntil := time.Unix(0, time.Now().UnixNano())
+
+	cases := []struct {
+		name  string
+		entry cacheEntry
+	}{
+		{"value with freshness", cacheEntry{freshUntil: freshUntil, payload: CacheData(`{"a":1}`)}},
+		{"value without freshness", cacheEntry{payload: CacheData(`{"a":1}`)}},
+		{"negative", cacheEntry{negative: true, payload: CacheData{}}},
+		{"codec and compression", cacheEntry{
+			codec:       CodecIdProto,
+			compression: CompressionZstd,
+			payload:     CacheData{0x0a, 0x01},
+		}},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			decoded := decodeCacheEntry(encodeCacheEntry(test.entry))
+
+			assert.Equal(t, test.entry.negative, decoded.negative)
+			assert.True(t, test.entry.freshUntil.Equal(decoded.freshUntil))
+			assert.Equal(t, test.entry.codec, decoded.codec)
+			assert.Equal(t, test.entry.compression, decoded.compression)
+			assert.Equal(t, test.entry.payload, decoded.payload)
+		})
+	}
+}
+
+func TestCacheEntryDecodeLegacyData(t *testing.T) {
+	data := CacheData(`{"name":"express"}`)
+	entry := decodeCacheEntry(data)
+
+	assert.False(t, entry.negative)
+	assert.False(t, entry.stale(time.Now()))
+	assert.Equal(t, data, entry.payload)
+}
+
+func TestCacheEntryDecodeVersion1(t *testing.T) {
+	freshUntil := time.Unix(0, time.Now().UnixNano())
+
+	data := CacheData{cacheEntryMagic, cacheEntryVersion1, cacheEntryFlagNegative}
+	data = binary.BigEndian.AppendUint64(data, uint64(freshUntil.UnixNano()))
+	data = append(data, []byte(`{}`)...)
+
+	entry := decodeCacheEntry(data)
+
+	assert.True(t, entry.negative)
+	assert.True(t, freshUntil.Equal(entry.freshUntil))
+	assert.Equal(t, CodecIdJson, entry.codec)
+	assert.Equal(t, CompressionNone, entry.compression)
+	assert.Equal(t, CacheData(`{}`), entry.payload)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -8,31 +9,87 @@ import (
 	"github.com/safedep/dry/log"
 )
 
+const (
+	unsafeMemoryCacheAdapterName = "unsafe_memory"
+)
+
 type memoryCache struct {
 	store sync.Map
 }
 
+type unsafeMemoryCacheEntry struct {
+	key  CacheKey
+	data *CacheData
+}
+
 // NewUnsafeMemoryCache returns an unbounded caching implementation not
 // suitable for real life use
-func NewUnsafeMemoryCache() Cache {
+//
+// Deprecated: Use NewMemoryCache which honors ttl and bounds memory usage
+func NewUnsafeMemoryCache() CacheAdapter {
 	return &memoryCache{
 		store: sync.Map{},
 	}
 }
 
 func (c *memoryCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.PutContext(context.Background(), key, data, ttl)
+}
+
+func (c *memoryCache) Get(key *CacheKey) (*CacheData, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+func (c *memoryCache) PutContext(_ context.Context, key *CacheKey, data *CacheData, ttl time.Duration) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(unsafeMemoryCacheAdapterName, metricOperationPut, key, start, err)
+	}(time.Now())
+
 	log.Debugf("Memory Cache: Storing %v", *key)
-	c.store.Store(fmt.Sprintf("%s/%s/%s", key.Source, key.Type, key.Id), data)
+	c.store.Store(cacheKeyString(key), &unsafeMemoryCacheEntry{key: *key, data: data})
 
 	return nil
 }
 
-func (c *memoryCache) Get(key *CacheKey) (*CacheData, error) {
+func (c *memoryCache) GetContext(_ context.Context, key *CacheKey) (_ *CacheData, err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(unsafeMemoryCacheAdapterName, metricOperationGet, key, start, err)
+	}(time.Now())
+
 	log.Debugf("Memory Cache: Loading %v", *key)
-	result, ok := c.store.Load(fmt.Sprintf("%s/%s/%s", key.Source, key.Type, key.Id))
+	result, ok := c.store.Load(cacheKeyString(key))
 	if !ok {
-		return nil, fmt.Errorf("key not in cache")
+		return nil, fmt.Errorf("key not in cache: %w", ErrCacheMiss)
 	}
 
-	return result.(*CacheData), nil
+	return result.(*unsafeMemoryCacheEntry).data, nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key *CacheKey) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(unsafeMemoryCacheAdapterName, metricOperationDelete, key, start, err)
+	}(time.Now())
+
+	c.store.Delete(cacheKeyString(key))
+	return nil
+}
+
+func (c *memoryCache) Invalidate(_ context.Context, filter InvalidationFilter) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(unsafeMemoryCacheAdapterName, metricOperationInvalidate, nil, start, err)
+	}(time.Now())
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	c.store.Range(func(k, v any) bool {
+		if filter.Matches(&v.(*unsafeMemoryCacheEntry).key) {
+			c.store.Delete(k)
+		}
+
+		return true
+	})
+
+	return nil
 }
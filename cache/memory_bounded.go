@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	memoryCacheAdapterName            = "memory"
+	memoryCacheDefaultMaxEntries      = 10000
+	memoryCacheDefaultJanitorInterval = time.Minute
+)
+
+var (
+	memoryCacheErrNonExistent = fmt.Errorf("cache key not found: %w", ErrCacheMiss)
+	memoryCacheErrExpired     = fmt.Errorf("cache entry expired: %w", ErrCacheMiss)
+	memoryCacheErrBadParams   = errors.New("bad params")
+	memoryCacheErrTooLarge    = errors.New("cache entry exceeds byte budget")
+)
+
+// MemoryCacheConfig configures a bounded in-memory cache
+type MemoryCacheConfig struct {
+	// Maximum number of entries held by the cache. Least recently used
+	// entries are evicted when the limit is reached. Defaults to 10000
+	MaxEntries int
+
+	// Maximum total size of cached data in bytes. Least recently used
+	// entries are evicted when the budget is exceeded. Zero means no
+	// byte budget is enforced
+	MaxBytes int64
+
+	// Interval at which expired entries are purged in the background.
+	// Defaults to 1 minute. A negative value disables the janitor, expired
+	// entries are then only dropped on access or eviction
+	JanitorInterval time.Duration
+}
+
+type memoryCacheEntry struct {
+	key       string
+	cacheKey  CacheKey
+	data      CacheData
+	expiresAt time.Time
+}
+
+func (e *memoryCacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type boundedMemoryCache struct {
+	config MemoryCacheConfig
+
+	m     sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+	bytes int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMemoryCache returns a bounded in-memory cache that honors the ttl
+// of each entry and evicts least recently used entries when the configured
+// limits are reached. Close must be called to stop the background janitor
+func NewMemoryCache(config MemoryCacheConfig) (ClosableCache, error) {
+	if config.MaxEntries < 0 || config.MaxBytes < 0 {
+		return nil, memoryCacheErrBadParams
+	}
+
+	if config.MaxEntries == 0 {
+		config.MaxEntries = memoryCacheDefaultMaxEntries
+	}
+
+	if config.JanitorInterval == 0 {
+		config.JanitorInterval = memoryCacheDefaultJanitorInterval
+	}
+
+	c := &boundedMemoryCache{
+		config: config,
+		items:  make(map[string]*list.Element),
+		lru:    list.New(),
+		done:   make(chan struct{}),
+	}
+
+	if config.JanitorInterval > 0 {
+		go c.janitor(config.JanitorInterval)
+	}
+
+	return c, nil
+}
+
+func (c *boundedMemoryCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.PutContext(context.Background(), key, data, ttl)
+}
+
+func (c *boundedMemoryCache) Get(key *CacheKey) (*CacheData, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// PutContext stores data against key. A ttl <= 0 stores an entry that
+// never expires but is still subject to eviction
+func (c *boundedMemoryCache) PutContext(_ context.Context, key *CacheKey, data *CacheData, ttl time.Duration) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(memoryCacheAdapterName, metricOperationPut, key, start, err)
+	}(time.Now())
+
+	if (key == nil) || (data == nil) {
+		return memoryCacheErrBadParams
+	}
+
+	size := int64(len(*data))
+	if c.config.MaxBytes > 0 && size > c.config.MaxBytes {
+		return memoryCacheErrTooLarge
+	}
+
+	entry := &memoryCacheEntry{
+		key:      cacheKeyString(key),
+		cacheKey: *key,
+		data:     append(CacheData(nil), (*data)...),
+	}
+
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, ok := c.items[entry.key]; ok {
+		c.removeElement(el)
+	}
+
+	c.items[entry.key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	c.evict()
+	return nil
+}
+
+func (c *boundedMemoryCache) GetContext(ctx context.Context, key *CacheKey) (*CacheData, error) {
+	data, _, err := c.GetWithExpiry(ctx, key)
+	return data, err
+}
+
+func (c *boundedMemoryCache) GetWithExpiry(_ context.Context, key *CacheKey) (_ *CacheData, _ time.Time, err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(memoryCacheAdapterName, metricOperationGet, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return nil, time.Time{}, memoryCacheErrBadParams
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	el, ok := c.items[cacheKeyString(key)]
+	if !ok {
+		return nil, time.Time{}, memoryCacheErrNonExistent
+	}
+
+	entry := el.Value.(*memoryCacheEntry)
+	if entry.expired(time.Now()) {
+		c.removeElement(el)
+		return nil, time.Time{}, memoryCacheErrExpired
+	}
+
+	c.lru.MoveToFront(el)
+
+	data := append(CacheData(nil), entry.data...)
+	return &data, entry.expiresAt, nil
+}
+
+func (c *boundedMemoryCache) Delete(_ context.Context, key *CacheKey) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(memoryCacheAdapterName, metricOperationDelete, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return memoryCacheErrBadParams
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, ok := c.items[cacheKeyString(key)]; ok {
+		c.removeElement(el)
+	}
+
+	return nil
+}
+
+func (c *boundedMemoryCache) Invalidate(_ context.Context, filter InvalidationFilter) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(memoryCacheAdapterName, metricOperationInvalidate, nil, start, err)
+	}(time.Now())
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if filter.Matches(&el.Value.(*memoryCacheEntry).cacheKey) {
+			c.removeElement(el)
+		}
+
+		el = prev
+	}
+
+	return nil
+}
+
+// Close stops the background janitor. It is safe to call multiple times
+func (c *boundedMemoryCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return nil
+}
+
+// Len returns the number of entries currently held, including entries
+// that have expired but are not yet purged
+func (c *boundedMemoryCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *boundedMemoryCache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.purgeExpired()
+		}
+	}
+}
+
+func (c *boundedMemoryCache) purgeExpired() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	now := time.Now()
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if el.Value.(*memoryCacheEntry).expired(now) {
+			c.removeElement(el)
+		}
+
+		el = prev
+	}
+}
+
+// evict drops least recently used entries until the cache is within its
+// limits. Must be called with the lock held
+func (c *boundedMemoryCache) evict() {
+	for c.lru.Len() > c.config.MaxEntries ||
+		(c.config.MaxBytes > 0 && c.bytes > c.config.MaxBytes) {
+		el := c.lru.Back()
+		if el == nil {
+			return
+		}
+
+		c.removeElement(el)
+	}
+}
+
+// removeElement must be called with the lock held
+func (c *boundedMemoryCache) removeElement(el *list.Element) {
+	entry := c.lru.Remove(el).(*memoryCacheEntry)
+	delete(c.items, entry.key)
+	c.bytes -= int64(len(entry.data))
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryCache(t *testing.T, config MemoryCacheConfig) *boundedMemoryCache {
+	t.Helper()
+
+	c, err := NewMemoryCache(config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = c.Close() })
+	return c.(*boundedMemoryCache)
+}
+
+func TestMemoryCachePutGet(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("value")
+
+	require.NoError(t, c.Put(key, &data, time.Minute))
+
+	// Mutating the caller's buffer must not affect the stored entry
+	data[0] = 'V'
+
+	res, err := c.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, CacheData("value"), *res)
+
+	_, err = c.Get(&CacheKey{Source: "npm", Type: "package", Id: "lodash"})
+	assert.ErrorIs(t, err, memoryCacheErrNonExistent)
+}
+
+func TestMemoryCacheBadParams(t *testing.T) {
+	_, err := NewMemoryCache(MemoryCacheConfig{MaxEntries: -1})
+	assert.ErrorIs(t, err, memoryCacheErrBadParams)
+
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+
+	assert.ErrorIs(t, c.Put(nil, &CacheData{}, time.Minute), memoryCacheErrBadParams)
+	assert.ErrorIs(t, c.Put(&CacheKey{}, nil, time.Minute), memoryCacheErrBadParams)
+
+	_, err = c.Get(nil)
+	assert.ErrorIs(t, err, memoryCacheErrBadParams)
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{JanitorInterval: -1})
+
+	key := &CacheKey{Source: "s", Type: "t", Id: "expiring"}
+	data := CacheData("value")
+	require.NoError(t, c.Put(key, &data, 10*time.Millisecond))
+
+	persistent := &CacheKey{Source: "s", Type: "t", Id: "persistent"}
+	require.NoError(t, c.Put(persistent, &data, 0))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := c.Get(key)
+	assert.ErrorIs(t, err, memoryCacheErrExpired)
+	assert.Equal(t, 1, c.Len())
+
+	_, err = c.Get(persistent)
+	assert.NoError(t, err)
+}
+
+func TestMemoryCacheJanitor(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{JanitorInterval: 5 * time.Millisecond})
+
+	data := CacheData("value")
+	require.NoError(t, c.Put(&CacheKey{Id: "a"}, &data, time.Millisecond))
+	require.NoError(t, c.Put(&CacheKey{Id: "b"}, &data, time.Hour))
+
+	assert.Eventually(t, func() bool {
+		return c.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cases := []struct {
+		name   string
+		config MemoryCacheConfig
+	}{
+		{"max entries", MemoryCacheConfig{MaxEntries: 2, JanitorInterval: -1}},
+		{"max bytes", MemoryCacheConfig{MaxBytes: 10, JanitorInterval: -1}},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestMemoryCache(t, test.config)
+
+			data := CacheData("12345")
+			a, b, d := &CacheKey{Id: "a"}, &CacheKey{Id: "b"}, &CacheKey{Id: "d"}
+
+			require.NoError(t, c.Put(a, &data, time.Hour))
+			require.NoError(t, c.Put(b, &data, time.Hour))
+
+			// Touch a so that b becomes the least recently used entry
+			_, err := c.Get(a)
+			require.NoError(t, err)
+
+			require.NoError(t, c.Put(d, &data, time.Hour))
+			assert.Equal(t, 2, c.Len())
+
+			_, err = c.Get(b)
+			assert.ErrorIs(t, err, memoryCacheErrNonExistent)
+
+			_, err = c.Get(a)
+			assert.NoError(t, err)
+
+			_, err = c.Get(d)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemoryCacheOverwriteTracksBytes(t *testing.T) {
+	c := newTestMemoryCache(t, MemoryCacheConfig{MaxBytes: 10, JanitorInterval: -1})
+
+	key := &CacheKey{Id: "a"}
+	small, large := CacheData("12"), CacheData("1234567890")
+
+	require.NoError(t, c.Put(key, &small, time.Hour))
+	require.NoError(t, c.Put(key, &large, time.Hour))
+	assert.Equal(t, int64(10), c.bytes)
+
+	tooLarge := CacheData("12345678901")
+	assert.ErrorIs(t, c.Put(key, &tooLarge, time.Hour), memoryCacheErrTooLarge)
+}
+
+func TestMemoryCacheCloseIsIdempotent(t *testing.T) {
+	c, err := NewMemoryCache(MemoryCacheConfig{})
+	require.NoError(t, err)
+
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close())
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/safedep/dry/log"
+	"github.com/safedep/dry/obs"
+)
+
+// Results reported for cache lookups and operations
+const (
+	metricResultHit      = "hit"
+	metricResultStale    = "stale"
+	metricResultNegative = "negative"
+	metricResultMiss     = "miss"
+	metricResultOk       = "ok"
+	metricResultError    = "error"
+)
+
+// Operations reported by adapters
+const (
+	metricOperationGet        = "get"
+	metricOperationPut        = "put"
+	metricOperationDelete     = "delete"
+	metricOperationInvalidate = "invalidate"
+)
+
+// Attributes recorded on the canonical log event
+const (
+	eventAttrCacheHits   = "cache.hits"
+	eventAttrCacheMisses = "cache.misses"
+	eventAttrCacheErrors = "cache.errors"
+)
+
+var (
+	metricCacheThroughTotal = obs.NewCounterVec(
+		"cache_through_total",
+		"Total number of read through cache lookups",
+		[]string{"source", "type", "result"},
+	)
+
+	metricCacheThroughDuration = obs.NewHistogramVec(
+		"cache_through_duration_seconds",
+		"Latency of read through cache lookups including loads on miss",
+		[]string{"source", "type", "result"},
+	)
+
+	metricCacheSerializationErrors = obs.NewCounterVec(
+		"cache_serialization_errors_total",
+		"Total number of failures to serialize or deserialize cached values",
+		[]string{"source", "type", "operation"},
+	)
+
+	metricCacheAdapterOperationsTotal = obs.NewCounterVec(
+		"cache_adapter_operations_total",
+		"Total number of operations performed on cache adapters",
+		[]string{"adapter", "operation", "source", "type", "result"},
+	)
+
+	metricCacheAdapterOperationDuration = obs.NewHistogramVec(
+		"cache_adapter_operation_duration_seconds",
+		"Latency of operations performed on cache adapters",
+		[]string{"adapter", "operation"},
+	)
+)
+
+// observeThrough records the outcome of a read through lookup in metrics
+// and in the canonical log event bound to ctx, if any
+func observeThrough(ctx context.Context, key *CacheKey, result string, start time.Time) {
+	labels := map[string]string{
+		"source": key.Source,
+		"type":   key.Type,
+		"result": result,
+	}
+
+	metricCacheThroughTotal.WithLabels(labels).Inc()
+	metricCacheThroughDuration.WithLabels(labels).Observe(time.Since(start).Seconds())
+
+	switch result {
+	case metricResultHit, metricResultStale, metricResultNegative:
+		log.Counter(ctx, eventAttrCacheHits, 1)
+	case metricResultMiss:
+		log.Counter(ctx, eventAttrCacheMisses, 1)
+	default:
+		log.Counter(ctx, eventAttrCacheErrors, 1)
+	}
+}
+
+func observeSerializationError(key *CacheKey, operation string) {
+	metricCacheSerializationErrors.WithLabels(map[string]string{
+		"source":    key.Source,
+		"type":      key.Type,
+		"operation": operation,
+	}).Inc()
+}
+
+// observeAdapterOperation records an adapter operation. Key is nil for
+// operations not bound to a key, such as invalidation
+func observeAdapterOperation(adapter, operation string, key *CacheKey, start time.Time, err error) {
+	source, typ := "", ""
+	if key != nil {
+		source, typ = key.Source, key.Type
+	}
+
+	metricCacheAdapterOperationsTotal.WithLabels(map[string]string{
+		"adapter":   adapter,
+		"operation": operation,
+		"source":    source,
+		"type":      typ,
+		"result":    adapterOperationResult(operation, err),
+	}).Inc()
+
+	metricCacheAdapterOperationDuration.WithLabels(map[string]string{
+		"adapter":   adapter,
+		"operation": operation,
+	}).Observe(time.Since(start).Seconds())
+}
+
+func adapterOperationResult(operation string, err error) string {
+	switch {
+	case err == nil && operation == metricOperationGet:
+		return metricResultHit
+	case err == nil:
+		return metricResultOk
+	case errors.Is(err, ErrCacheMiss):
+		return metricResultMiss
+	default:
+		return metricResultError
+	}
+}
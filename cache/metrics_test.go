@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/safedep/dry/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapterOperationResult(t *testing.T) {
+	cases := []struct {
+		name      string
+		operation string
+		err       error
+		result    string
+	}{
+		{"get hit", metricOperationGet, nil, metricResultHit},
+		{"get miss", metricOperationGet, fmt.Errorf("expired: %w", ErrCacheMiss), metricResultMiss},
+		{"get error", metricOperationGet, errors.New("db down"), metricResultError},
+		{"put ok", metricOperationPut, nil, metricResultOk},
+		{"put error", metricOperationPut, errors.New("db down"), metricResultError},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.result, adapterOperationResult(test.operation, test.err))
+		})
+	}
+}
+
+func TestThroughContextRecordsCanonicalEventCounters(t *testing.T) {
+	var buf bytes.Buffer
+	restore := log.SwapGlobalForTest(&buf)
+	defer restore()
+
+	c := newTestMemoryCache(t, MemoryCacheConfig{})
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	fun := func(_ context.Context) (testCacheValue, error) {
+		return testCacheValue{Name: "express"}, nil
+	}
+
+	ctx, end := log.BeginEvent(context.Background(), "test.event")
+	for i := 0; i < 3; i++ {
+		_, err := ThroughContext(ctx, c, key, time.Minute, fun)
+		require.NoError(t, err)
+	}
+	end()
+
+	var record map[string]any
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &record))
+
+	assert.Equal(t, "test.event", record["event"])
+	assert.Equal(t, float64(2), record[eventAttrCacheHits])
+	assert.Equal(t, float64(1), record[eventAttrCacheMisses])
+	assert.NotContains(t, record, eventAttrCacheErrors)
+}
@@ -1,21 +1,26 @@
 package cache
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/safedep/dry/db"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
-	mysqlCacheTableName = "caches"
+	mysqlCacheAdapterName = "mysql"
+	mysqlCacheTableName   = "caches"
 )
 
 var (
-	mysqlCacheErrExpired      = errors.New("cache entry expired")
-	mysqlCacheErrNonExistent  = errors.New("cache key not found")
+	mysqlCacheErrExpired      = fmt.Errorf("cache entry expired: %w", ErrCacheMiss)
+	mysqlCacheErrNonExistent  = fmt.Errorf("cache key not found: %w", ErrCacheMiss)
 	mysqlCacheErrBadParams    = errors.New("bad params")
 	mysqlCacheErrActiveExists = errors.New("cache entry exists and active")
 )
@@ -52,7 +57,11 @@ type MySqlCacheConfig struct {
 	MaxOpenConnections int
 }
 
-func NewMySqlCache(config MySqlCacheConfig) (Cache, error) {
+// NewMySqlCache returns a cache persisted in a MySQL database
+//
+// Deprecated: Use NewSqlCache which supports MySQL and PostgreSQL, long
+// keys and removal of expired entries
+func NewMySqlCache(config MySqlCacheConfig) (CacheAdapter, error) {
 	if config.MaxIdleTime == 0 {
 		config.MaxIdleTime = 60 * time.Second
 	}
@@ -92,6 +101,18 @@ func NewMySqlCache(config MySqlCacheConfig) (Cache, error) {
 }
 
 func (mcache *mysqlCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return mcache.PutContext(context.Background(), key, data, ttl)
+}
+
+func (mcache *mysqlCache) Get(key *CacheKey) (*CacheData, error) {
+	return mcache.GetContext(context.Background(), key)
+}
+
+func (mcache *mysqlCache) PutContext(ctx context.Context, key *CacheKey, data *CacheData, ttl time.Duration) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(mysqlCacheAdapterName, metricOperationPut, key, start, err)
+	}(time.Now())
+
 	if (key == nil) || (data == nil) {
 		return mysqlCacheErrBadParams
 	}
@@ -104,25 +125,109 @@ func (mcache *mysqlCache) Put(key *CacheKey, data *CacheData, ttl time.Duration)
 		Data:      []byte(*data),
 	}
 
-	return mcache.createEntry(&entry)
+	return mcache.createEntry(ctx, &entry)
 }
 
-func (mcache *mysqlCache) Get(key *CacheKey) (*CacheData, error) {
-	record, err := mcache.findByCacheKey(key)
+func (mcache *mysqlCache) GetContext(ctx context.Context, key *CacheKey) (*CacheData, error) {
+	data, _, err := mcache.GetWithExpiry(ctx, key)
+	return data, err
+}
+
+func (mcache *mysqlCache) GetWithExpiry(ctx context.Context, key *CacheKey) (_ *CacheData, _ time.Time, err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(mysqlCacheAdapterName, metricOperationGet, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return nil, time.Time{}, mysqlCacheErrBadParams
+	}
+
+	record, err := mcache.findByCacheKey(ctx, key)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, time.Time{}, mysqlCacheErrNonExistent
+		}
+
+		return nil, time.Time{}, err
 	}
 
-	if record.ExpiresAt.Before(time.Now()) {
-		_ = mcache.deleteEntry(&record)
-		return nil, mysqlCacheErrExpired
+	now := time.Now()
+	if record.ExpiresAt.Before(now) {
+		_ = mcache.deleteExpiredEntry(ctx, &record, now)
+		return nil, time.Time{}, mysqlCacheErrExpired
 	}
 
 	data := CacheData(record.Data)
-	return &data, nil
+	return &data, record.ExpiresAt, nil
 }
 
-func (mcache *mysqlCache) findByCacheKey(key *CacheKey) (mysqlCacheEntry, error) {
+func (mcache *mysqlCache) Delete(ctx context.Context, key *CacheKey) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(mysqlCacheAdapterName, metricOperationDelete, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return mysqlCacheErrBadParams
+	}
+
+	db, err := mcache.mysqlAdapter.GetDB()
+	if err != nil {
+		return err
+	}
+
+	// Map conditions are used so that empty key attributes are not
+	// dropped from the query. Force hard delete
+	tx := db.WithContext(ctx).Unscoped().Where(map[string]any{
+		"source": key.Source,
+		"type":   key.Type,
+		"key":    key.Id,
+	}).Delete(&mysqlCacheEntry{})
+
+	return tx.Error
+}
+
+func (mcache *mysqlCache) Invalidate(ctx context.Context, filter InvalidationFilter) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(mysqlCacheAdapterName, metricOperationInvalidate, nil, start, err)
+	}(time.Now())
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	db, err := mcache.mysqlAdapter.GetDB()
+	if err != nil {
+		return err
+	}
+
+	tx := db.WithContext(ctx).Unscoped().Where(clause.Eq{
+		Column: clause.Column{Name: "source"},
+		Value:  filter.Source,
+	})
+
+	if filter.Type != "" {
+		tx = tx.Where(clause.Eq{Column: clause.Column{Name: "type"}, Value: filter.Type})
+	}
+
+	if filter.IdPrefix != "" {
+		tx = tx.Where(clause.Like{
+			Column: clause.Column{Name: "key"},
+			Value:  mysqlCacheEscapeLike(filter.IdPrefix) + "%",
+		})
+	}
+
+	// Force hard delete
+	tx = tx.Delete(&mysqlCacheEntry{})
+	return tx.Error
+}
+
+// mysqlCacheEscapeLike escapes LIKE wildcards using the default
+// backslash escape character
+func mysqlCacheEscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (mcache *mysqlCache) findByCacheKey(ctx context.Context, key *CacheKey) (mysqlCacheEntry, error) {
 	var record mysqlCacheEntry
 
 	db, err := mcache.mysqlAdapter.GetDB()
@@ -131,7 +236,7 @@ func (mcache *mysqlCache) findByCacheKey(key *CacheKey) (mysqlCacheEntry, error)
 	}
 
 	// Avoid soft delete flag
-	tx := db.Unscoped().Where(&mysqlCacheEntry{
+	tx := db.WithContext(ctx).Unscoped().Where(&mysqlCacheEntry{
 		Source: key.Source,
 		Type:   key.Type,
 		Key:    key.Id,
@@ -144,23 +249,34 @@ func (mcache *mysqlCache) findByCacheKey(key *CacheKey) (mysqlCacheEntry, error)
 	return record, nil
 }
 
-func (mcache *mysqlCache) createEntry(entry *mysqlCacheEntry) error {
+func (mcache *mysqlCache) createEntry(ctx context.Context, entry *mysqlCacheEntry) error {
 	db, err := mcache.mysqlAdapter.GetDB()
 	if err != nil {
 		return err
 	}
 
-	tx := db.Create(entry)
+	// Overwrite existing entries, including entries kept beyond their
+	// freshness for stale-while-revalidate
+	tx := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "type"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "expires_at", "updated_at"}),
+	}).Create(entry)
+
 	return tx.Error
 }
 
-func (mcache *mysqlCache) deleteEntry(entry *mysqlCacheEntry) error {
+// deleteExpiredEntry deletes an expired entry. The upsert keeps the row id,
+// so the row is deleted only when it was not refreshed since it was read
+func (mcache *mysqlCache) deleteExpiredEntry(ctx context.Context, entry *mysqlCacheEntry, now time.Time) error {
 	db, err := mcache.mysqlAdapter.GetDB()
 	if err != nil {
 		return err
 	}
 
 	// Force hard delete
-	tx := db.Unscoped().Delete(entry)
+	tx := db.WithContext(ctx).Unscoped().
+		Where("id = ? AND expires_at <= ?", entry.ID, now).
+		Delete(&mysqlCacheEntry{})
+
 	return tx.Error
 }
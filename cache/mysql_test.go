@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestMySqlCache(t *testing.T) *mysqlCache {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gdb.AutoMigrate(&mysqlCacheEntry{}))
+
+	return &mysqlCache{mysqlAdapter: testSqlAdapter{gdb: gdb}}
+}
+
+func TestMySqlCachePutOverwritesExistingEntry(t *testing.T) {
+	c := newTestMySqlCache(t)
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	first := CacheData("first")
+	require.NoError(t, c.Put(key, &first, time.Minute))
+
+	second := CacheData("second")
+	require.NoError(t, c.Put(key, &second, time.Hour))
+
+	data, expiresAt, err := c.GetWithExpiry(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(*data))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+}
+
+func TestMySqlCacheStaleWhileRevalidateRefreshesEntry(t *testing.T) {
+	c := newTestMySqlCache(t)
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	var calls atomic.Int32
+	fun := func(_ context.Context) (testCacheValue, error) {
+		n := calls.Add(1)
+		return testCacheValue{Name: "express", Count: int(n)}, nil
+	}
+
+	ttl := 50 * time.Millisecond
+	opt := WithStaleWhileRevalidate(time.Minute)
+
+	res, err := ThroughContext(context.Background(), c, key, ttl, fun, opt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Count)
+
+	time.Sleep(2 * ttl)
+
+	// The stale row is still live, so the refresh must overwrite it
+	res, err = ThroughContext(context.Background(), c, key, ttl, fun, opt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Count)
+
+	assert.Eventually(t, func() bool {
+		res, err := ThroughContext(context.Background(), c, key, time.Minute, fun, opt)
+		return err == nil && res.Count == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestMySqlCacheExpiredReadKeepsRefreshedEntry(t *testing.T) {
+	c := newTestMySqlCache(t)
+	ctx := context.Background()
+	key := &CacheKey{Source: "s", Type: "t", Id: "refreshed"}
+
+	stale := CacheData("stale")
+	require.NoError(t, c.PutContext(ctx, key, &stale, 10*time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+
+	gdb, err := c.mysqlAdapter.GetDB()
+	require.NoError(t, err)
+
+	// Refresh the entry between the read of the expired row and its delete
+	fresh := CacheData("fresh")
+	refreshed := false
+	require.NoError(t, gdb.Callback().Delete().Before("gorm:delete").Register("test:refresh", func(*gorm.DB) {
+		if !refreshed {
+			refreshed = true
+			require.NoError(t, c.PutContext(ctx, key, &fresh, time.Minute))
+		}
+	}))
+
+	_, err = c.GetContext(ctx, key)
+	assert.ErrorIs(t, err, mysqlCacheErrExpired)
+	assert.True(t, refreshed)
+
+	data, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", string(*data))
+}
@@ -1,13 +1,14 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
 type noCache struct{}
 
-func newNoCache() Cache {
+func newNoCache() CacheAdapter {
 	return &noCache{}
 }
 
@@ -16,5 +17,21 @@ func (c *noCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
 }
 
 func (c *noCache) Get(key *CacheKey) (*CacheData, error) {
-	return nil, fmt.Errorf("nocache: no get")
+	return nil, fmt.Errorf("nocache: no get: %w", ErrCacheMiss)
+}
+
+func (c *noCache) PutContext(_ context.Context, key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.Put(key, data, ttl)
+}
+
+func (c *noCache) GetContext(_ context.Context, key *CacheKey) (*CacheData, error) {
+	return c.Get(key)
+}
+
+func (c *noCache) Delete(_ context.Context, _ *CacheKey) error {
+	return nil
+}
+
+func (c *noCache) Invalidate(_ context.Context, _ InvalidationFilter) error {
+	return nil
 }
@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/safedep/dry/db"
+	"github.com/safedep/dry/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	sqlCacheAdapterName          = "sql"
+	sqlCacheTableName            = "cache_entries"
+	sqlCacheDefaultSweepInterval = time.Minute
+	sqlCacheDefaultSweepBatch    = 1000
+)
+
+var (
+	sqlCacheErrExpired     = fmt.Errorf("cache entry expired: %w", ErrCacheMiss)
+	sqlCacheErrNonExistent = fmt.Errorf("cache key not found: %w", ErrCacheMiss)
+	sqlCacheErrBadParams   = errors.New("bad params")
+)
+
+// sqlCacheEntry is the cache table. Entries are looked up by a hash of the
+// cache key so that long keys are neither truncated nor bound by index
+// length limits. The full key is kept for invalidation and collision checks
+type sqlCacheEntry struct {
+	ID uint64 `gorm:"primaryKey;autoIncrement"`
+
+	// sha256 (hex) of the cache key
+	KeyHash string `gorm:"column:key_hash;type:char(64);not null;uniqueIndex:idx_cache_entries_key_hash"`
+
+	Source string `gorm:"column:source;type:varchar(255);not null;index:idx_cache_entries_source_type,priority:1"`
+	Type   string `gorm:"column:type;type:varchar(255);not null;index:idx_cache_entries_source_type,priority:2"`
+	Id     string `gorm:"column:cache_id;type:text;not null"`
+
+	Data []byte `gorm:"column:data;not null"`
+
+	// Nil when the entry never expires
+	ExpiresAt *time.Time `gorm:"column:expires_at;index:idx_cache_entries_expires_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;index:idx_cache_entries_updated_at"`
+}
+
+func (sqlCacheEntry) TableName() string {
+	return sqlCacheTableName
+}
+
+// MigrateSqlCache creates or updates the cache table via the adapter
+func MigrateSqlCache(adapter db.SqlDataAdapter) error {
+	return adapter.Migrate(&sqlCacheEntry{})
+}
+
+// SqlCacheConfig configures a cache persisted in a MySQL or PostgreSQL
+// database through db.SqlDataAdapter
+type SqlCacheConfig struct {
+	// Interval at which expired entries are swept and the size limit is
+	// enforced. Defaults to 1 minute. A negative value disables the sweeper
+	SweepInterval time.Duration
+
+	// Maximum number of rows deleted per statement by the sweeper.
+	// Defaults to 1000
+	SweepBatchSize int
+
+	// Maximum number of entries in the table. Least recently written
+	// entries are removed by the sweeper when exceeded. Zero means no limit
+	MaxEntries int64
+
+	// Skip creating the cache table. Use when schema is managed through
+	// the consumer's migration pipeline using MigrateSqlCache
+	SkipMigration bool
+}
+
+type sqlCache struct {
+	adapter db.SqlDataAdapter
+	config  SqlCacheConfig
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSqlCache returns a cache persisted in a MySQL or PostgreSQL database.
+// Close must be called to stop the background sweeper
+func NewSqlCache(adapter db.SqlDataAdapter, config SqlCacheConfig) (ClosableCache, error) {
+	if adapter == nil || config.SweepBatchSize < 0 || config.MaxEntries < 0 {
+		return nil, sqlCacheErrBadParams
+	}
+
+	if config.SweepInterval == 0 {
+		config.SweepInterval = sqlCacheDefaultSweepInterval
+	}
+
+	if config.SweepBatchSize == 0 {
+		config.SweepBatchSize = sqlCacheDefaultSweepBatch
+	}
+
+	if !config.SkipMigration {
+		if err := MigrateSqlCache(adapter); err != nil {
+			return nil, fmt.Errorf("sql cache: failed to migrate: %w", err)
+		}
+	}
+
+	c := &sqlCache{
+		adapter: adapter,
+		config:  config,
+		done:    make(chan struct{}),
+	}
+
+	if config.SweepInterval > 0 {
+		go c.sweeper(config.SweepInterval)
+	}
+
+	return c, nil
+}
+
+func (c *sqlCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.PutContext(context.Background(), key, data, ttl)
+}
+
+func (c *sqlCache) Get(key *CacheKey) (*CacheData, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// PutContext creates or replaces the entry for key. A ttl <= 0 stores an
+// entry that never expires but is still subject to the size limit
+func (c *sqlCache) PutContext(ctx context.Context, key *CacheKey, data *CacheData, ttl time.Duration) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqlCacheAdapterName, metricOperationPut, key, start, err)
+	}(time.Now())
+
+	if (key == nil) || (data == nil) {
+		return sqlCacheErrBadParams
+	}
+
+	gdb, err := c.adapter.GetDB()
+	if err != nil {
+		return err
+	}
+
+	entry := sqlCacheEntry{
+		KeyHash: sqlCacheKeyHash(key),
+		Source:  key.Source,
+		Type:    key.Type,
+		Id:      key.Id,
+		Data:    []byte(*data),
+	}
+
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	return gdb.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "expires_at", "updated_at"}),
+	}).Create(&entry).Error
+}
+
+func (c *sqlCache) GetContext(ctx context.Context, key *CacheKey) (*CacheData, error) {
+	data, _, err := c.GetWithExpiry(ctx, key)
+	return data, err
+}
+
+func (c *sqlCache) GetWithExpiry(ctx context.Context, key *CacheKey) (_ *CacheData, _ time.Time, err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqlCacheAdapterName, metricOperationGet, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return nil, time.Time{}, sqlCacheErrBadParams
+	}
+
+	gdb, err := c.adapter.GetDB()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var entry sqlCacheEntry
+	err = gdb.WithContext(ctx).
+		Where("key_hash = ?", sqlCacheKeyHash(key)).
+		Take(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, time.Time{}, sqlCacheErrNonExistent
+		}
+
+		return nil, time.Time{}, err
+	}
+
+	// Guard against hash collisions
+	if entry.Source != key.Source || entry.Type != key.Type || entry.Id != key.Id {
+		return nil, time.Time{}, sqlCacheErrNonExistent
+	}
+
+	data := CacheData(entry.Data)
+	if entry.ExpiresAt == nil {
+		return &data, time.Time{}, nil
+	}
+
+	now := time.Now()
+	if !now.Before(*entry.ExpiresAt) {
+		// The upsert keeps the row id, so the row is deleted only when it was
+		// not refreshed since it was read. Left for the sweeper when the
+		// delete fails
+		_ = gdb.WithContext(ctx).
+			Where("id = ? AND expires_at <= ?", entry.ID, now).
+			Delete(&sqlCacheEntry{}).Error
+
+		return nil, time.Time{}, sqlCacheErrExpired
+	}
+
+	return &data, *entry.ExpiresAt, nil
+}
+
+func (c *sqlCache) Delete(ctx context.Context, key *CacheKey) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqlCacheAdapterName, metricOperationDelete, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return sqlCacheErrBadParams
+	}
+
+	gdb, err := c.adapter.GetDB()
+	if err != nil {
+		return err
+	}
+
+	return gdb.WithContext(ctx).
+		Where("key_hash = ?", sqlCacheKeyHash(key)).
+		Delete(&sqlCacheEntry{}).Error
+}
+
+func (c *sqlCache) Invalidate(ctx context.Context, filter InvalidationFilter) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqlCacheAdapterName, metricOperationInvalidate, nil, start, err)
+	}(time.Now())
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	gdb, err := c.adapter.GetDB()
+	if err != nil {
+		return err
+	}
+
+	tx := gdb.WithContext(ctx).Where("source = ?", filter.Source)
+	if filter.Type != "" {
+		tx = tx.Where("type = ?", filter.Type)
+	}
+
+	if filter.IdPrefix != "" {
+		// LIKE is case insensitive with the default collations of MySQL.
+		// Comparing with a binary string makes the match exact
+		query := `cache_id LIKE ? ESCAPE '!'`
+		if gdb.Dialector.Name() == "mysql" {
+			query = `cache_id LIKE CAST(? AS BINARY) ESCAPE '!'`
+		}
+
+		tx = tx.Where(query, sqlCacheEscapeLike(filter.IdPrefix)+"%")
+	}
+
+	return tx.Delete(&sqlCacheEntry{}).Error
+}
+
+// Close stops the background sweeper. The adapter is owned by the caller
+// and is not closed. It is safe to call multiple times
+func (c *sqlCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return nil
+}
+
+func (c *sqlCache) sweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if _, err := c.sweep(context.Background()); err != nil {
+				log.Warnf("SQL Cache: Failed to sweep entries: %v", err)
+			}
+		}
+	}
+}
+
+// sweep deletes expired entries followed by the least recently written
+// entries exceeding MaxEntries, one batch at a time so that a large backlog
+// does not hold long running locks. Returns the number of entries deleted
+func (c *sqlCache) sweep(ctx context.Context) (int64, error) {
+	gdb, err := c.adapter.GetDB()
+	if err != nil {
+		return 0, err
+	}
+
+	gdb = gdb.WithContext(ctx)
+
+	var deleted int64
+	for {
+		n, err := c.deleteBatch(gdb, gdb.Model(&sqlCacheEntry{}).
+			Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).
+			Order("expires_at ASC"), c.config.SweepBatchSize)
+
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("sql cache: failed to sweep expired entries: %w", err)
+		}
+
+		if n < int64(c.config.SweepBatchSize) {
+			break
+		}
+	}
+
+	if c.config.MaxEntries == 0 {
+		return deleted, nil
+	}
+
+	var count int64
+	if err := gdb.Model(&sqlCacheEntry{}).Count(&count).Error; err != nil {
+		return deleted, fmt.Errorf("sql cache: failed to count entries: %w", err)
+	}
+
+	for excess := count - c.config.MaxEntries; excess > 0; {
+		limit := min(excess, int64(c.config.SweepBatchSize))
+		n, err := c.deleteBatch(gdb, gdb.Model(&sqlCacheEntry{}).
+			Order("updated_at ASC").Order("id ASC"), int(limit))
+
+		deleted += n
+		excess -= n
+
+		if err != nil {
+			return deleted, fmt.Errorf("sql cache: failed to enforce size limit: %w", err)
+		}
+
+		if n == 0 {
+			break
+		}
+	}
+
+	return deleted, nil
+}
+
+// deleteBatch deletes up to limit rows selected by query. Ids are selected
+// first since MySQL does not support LIMIT in a DELETE sub-query
+func (c *sqlCache) deleteBatch(gdb, query *gorm.DB, limit int) (int64, error) {
+	var ids []uint64
+	if err := query.Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res := gdb.Where("id IN ?", ids).Delete(&sqlCacheEntry{})
+	return res.RowsAffected, res.Error
+}
+
+func sqlCacheKeyHash(key *CacheKey) string {
+	// Length prefixed to avoid ambiguity between fields
+	h := sha256.New()
+	for _, s := range []string{key.Source, key.Type, key.Id} {
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sqlCacheEscapeLike escapes LIKE wildcards using an explicit escape
+// character since the default differs between MySQL and PostgreSQL
+func sqlCacheEscapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testSqlAdapter struct{ gdb *gorm.DB }
+
+func (a testSqlAdapter) GetDB() (*gorm.DB, error)  { return a.gdb, nil }
+func (a testSqlAdapter) GetConn() (*sql.DB, error) { return a.gdb.DB() }
+func (a testSqlAdapter) Migrate(models ...interface{}) error {
+	return a.gdb.AutoMigrate(models...)
+}
+func (a testSqlAdapter) Ping() error {
+	c, err := a.gdb.DB()
+	if err != nil {
+		return err
+	}
+	return c.Ping()
+}
+
+func newTestSqlCache(t *testing.T, config SqlCacheConfig) *sqlCache {
+	t.Helper()
+
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	if config.SweepInterval == 0 {
+		config.SweepInterval = -1
+	}
+
+	c, err := NewSqlCache(testSqlAdapter{gdb: gdb}, config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = c.Close() })
+	return c.(*sqlCache)
+}
+
+func (c *sqlCache) countEntries(t *testing.T) int64 {
+	t.Helper()
+
+	gdb, err := c.adapter.GetDB()
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, gdb.Model(&sqlCacheEntry{}).Count(&count).Error)
+	return count
+}
+
+func TestSqlCacheBadParams(t *testing.T) {
+	_, err := NewSqlCache(nil, SqlCacheConfig{})
+	assert.ErrorIs(t, err, sqlCacheErrBadParams)
+
+	c := newTestSqlCache(t, SqlCacheConfig{})
+	assert.ErrorIs(t, c.Put(nil, &CacheData{}, time.Minute), sqlCacheErrBadParams)
+
+	_, err = c.Get(nil)
+	assert.ErrorIs(t, err, sqlCacheErrBadParams)
+}
+
+func TestSqlCachePutGetLongKeys(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{})
+	ctx := context.Background()
+
+	key := &CacheKey{
+		Source: "npm",
+		Type:   "package",
+		Id:     "@scope/" + strings.Repeat("very-long-package-name-", 20),
+	}
+
+	data := CacheData("v1")
+	require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+
+	data = CacheData("v2")
+	require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+	assert.Equal(t, int64(1), c.countEntries(t))
+
+	res, expiresAt, err := c.GetWithExpiry(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, CacheData("v2"), *res)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	// Same id prefix must not collide after truncation
+	_, err = c.GetContext(ctx, &CacheKey{Source: "npm", Type: "package", Id: key.Id[:100]})
+	assert.ErrorIs(t, err, sqlCacheErrNonExistent)
+
+	require.NoError(t, c.Delete(ctx, key))
+
+	_, err = c.GetContext(ctx, key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestSqlCacheKeyHashIsUnambiguous(t *testing.T) {
+	a := sqlCacheKeyHash(&CacheKey{Source: "a/b", Type: "c", Id: "d"})
+	b := sqlCacheKeyHash(&CacheKey{Source: "a", Type: "b/c", Id: "d"})
+
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 64)
+}
+
+func TestSqlCacheExpiry(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{})
+	ctx := context.Background()
+	data := CacheData("value")
+
+	expiring := &CacheKey{Source: "s", Type: "t", Id: "expiring"}
+	require.NoError(t, c.PutContext(ctx, expiring, &data, 10*time.Millisecond))
+
+	persistent := &CacheKey{Source: "s", Type: "t", Id: "persistent"}
+	require.NoError(t, c.PutContext(ctx, persistent, &data, 0))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := c.GetContext(ctx, expiring)
+	assert.ErrorIs(t, err, sqlCacheErrExpired)
+	assert.Equal(t, int64(1), c.countEntries(t))
+
+	_, expiresAt, err := c.GetWithExpiry(ctx, persistent)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.IsZero())
+}
+
+func TestSqlCacheExpiredReadKeepsRefreshedEntry(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{})
+	ctx := context.Background()
+	key := &CacheKey{Source: "s", Type: "t", Id: "refreshed"}
+
+	stale := CacheData("stale")
+	require.NoError(t, c.PutContext(ctx, key, &stale, 10*time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+
+	gdb, err := c.adapter.GetDB()
+	require.NoError(t, err)
+
+	// Refresh the entry between the read of the expired row and its delete
+	fresh := CacheData("fresh")
+	refreshed := false
+	require.NoError(t, gdb.Callback().Delete().Before("gorm:delete").Register("test:refresh", func(*gorm.DB) {
+		if !refreshed {
+			refreshed = true
+			require.NoError(t, c.PutContext(ctx, key, &fresh, time.Minute))
+		}
+	}))
+
+	_, err = c.GetContext(ctx, key)
+	assert.ErrorIs(t, err, sqlCacheErrExpired)
+	assert.True(t, refreshed)
+
+	data, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", string(*data))
+}
+
+func TestSqlCacheSweepExpiredInBatches(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{SweepBatchSize: 3})
+	ctx := context.Background()
+	data := CacheData("value")
+
+	for i := 0; i < 10; i++ {
+		key := &CacheKey{Source: "s", Type: "t", Id: fmt.Sprintf("expiring-%d", i)}
+		require.NoError(t, c.PutContext(ctx, key, &data, time.Millisecond))
+	}
+
+	for i := 0; i < 2; i++ {
+		key := &CacheKey{Source: "s", Type: "t", Id: fmt.Sprintf("active-%d", i)}
+		require.NoError(t, c.PutContext(ctx, key, &data, time.Hour))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	deleted, err := c.sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), deleted)
+	assert.Equal(t, int64(2), c.countEntries(t))
+}
+
+func TestSqlCacheSweepEnforcesMaxEntries(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{SweepBatchSize: 2, MaxEntries: 3})
+	ctx := context.Background()
+	data := CacheData("value")
+
+	for i := 0; i < 8; i++ {
+		key := &CacheKey{Source: "s", Type: "t", Id: fmt.Sprintf("key-%d", i)}
+		require.NoError(t, c.PutContext(ctx, key, &data, time.Hour))
+	}
+
+	deleted, err := c.sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+	assert.Equal(t, int64(3), c.countEntries(t))
+
+	// Most recently written entries are retained
+	for i := 5; i < 8; i++ {
+		_, err := c.GetContext(ctx, &CacheKey{Source: "s", Type: "t", Id: fmt.Sprintf("key-%d", i)})
+		assert.NoError(t, err)
+	}
+}
+
+func TestSqlCacheInvalidate(t *testing.T) {
+	c := newTestSqlCache(t, SqlCacheConfig{})
+	ctx := context.Background()
+	data := CacheData("value")
+
+	keys := []*CacheKey{
+		{Source: "npm", Type: "package", Id: "@angular/core"},
+		{Source: "npm", Type: "package", Id: "@angular_x/core"},
+		{Source: "npm", Type: "package", Id: "express"},
+		{Source: "npm", Type: "version", Id: "@angular/core"},
+		{Source: "pypi", Type: "package", Id: "requests"},
+	}
+
+	for _, key := range keys {
+		require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+	}
+
+	// Wildcards in the prefix are matched literally
+	require.NoError(t, c.Invalidate(ctx, InvalidationFilter{
+		Source:   "npm",
+		Type:     "package",
+		IdPrefix: "@angular_",
+	}))
+
+	_, err := c.GetContext(ctx, keys[0])
+	assert.NoError(t, err)
+
+	_, err = c.GetContext(ctx, keys[1])
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, c.Invalidate(ctx, InvalidationFilter{Source: "npm"}))
+	assert.Equal(t, int64(1), c.countEntries(t))
+}
+
+func TestSqlCacheInvalidateMySqlIsCaseSensitive(t *testing.T) {
+	gdb, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	var statements []string
+	require.NoError(t, gdb.Callback().Delete().After("gorm:delete").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}))
+
+	c, err := NewSqlCache(testSqlAdapter{gdb: gdb}, SqlCacheConfig{SweepInterval: -1, SkipMigration: true})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	require.NoError(t, c.Invalidate(context.Background(), InvalidationFilter{
+		Source:   "npm",
+		Type:     "package",
+		IdPrefix: "@angular/",
+	}))
+
+	require.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "cache_id LIKE CAST(? AS BINARY) ESCAPE '!'")
+}
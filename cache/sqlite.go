@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/safedep/dry/localdb"
+	"github.com/safedep/dry/log"
+)
+
+const (
+	sqliteCacheAdapterName          = "sqlite"
+	sqliteCacheModuleName           = "dry_cache"
+	sqliteCacheDefaultPurgeInterval = 10 * time.Minute
+)
+
+// sqliteCacheMigrations is append-only. Never edit or reorder an entry
+var sqliteCacheMigrations = []string{
+	`CREATE TABLE dry_cache_entries (
+		source     TEXT    NOT NULL,
+		type       TEXT    NOT NULL,
+		id         TEXT    NOT NULL,
+		data       BLOB    NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (source, type, id)
+	)`,
+	`CREATE INDEX dry_cache_entries_expires_at_idx ON dry_cache_entries (expires_at)`,
+}
+
+var (
+	sqliteCacheErrExpired     = fmt.Errorf("cache entry expired: %w", ErrCacheMiss)
+	sqliteCacheErrNonExistent = fmt.Errorf("cache key not found: %w", ErrCacheMiss)
+	sqliteCacheErrBadParams   = errors.New("bad params")
+)
+
+// SqliteCacheConfig configures a cache persisted in a local SQLite
+// database managed by localdb
+type SqliteCacheConfig struct {
+	// Manager owning the shared local database. The cache does not close it
+	Manager localdb.Manager
+
+	// Interval at which expired entries are purged in the background.
+	// Defaults to 10 minutes. A negative value disables the purger
+	PurgeInterval time.Duration
+}
+
+type sqliteCache struct {
+	db *sql.DB
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSqliteCache returns a cache persisted in the local database of a
+// localdb.Manager. Entries survive across process runs. Close must be called
+// to stop the background purger
+func NewSqliteCache(ctx context.Context, config SqliteCacheConfig) (ClosableCache, error) {
+	if config.Manager == nil {
+		return nil, sqliteCacheErrBadParams
+	}
+
+	if config.PurgeInterval == 0 {
+		config.PurgeInterval = sqliteCacheDefaultPurgeInterval
+	}
+
+	store, err := config.Manager.Store(ctx, localdb.Descriptor{
+		Name:       sqliteCacheModuleName,
+		Migrations: sqliteCacheMigrations,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &sqliteCache{
+		db:   store.DB(),
+		done: make(chan struct{}),
+	}
+
+	if config.PurgeInterval > 0 {
+		go c.purger(config.PurgeInterval)
+	}
+
+	return c, nil
+}
+
+func (c *sqliteCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.PutContext(context.Background(), key, data, ttl)
+}
+
+func (c *sqliteCache) Get(key *CacheKey) (*CacheData, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// PutContext stores data against key. A ttl <= 0 stores an entry that
+// never expires
+func (c *sqliteCache) PutContext(ctx context.Context, key *CacheKey, data *CacheData, ttl time.Duration) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqliteCacheAdapterName, metricOperationPut, key, start, err)
+	}(time.Now())
+
+	if (key == nil) || (data == nil) {
+		return sqliteCacheErrBadParams
+	}
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	_, err = c.db.ExecContext(ctx,
+		`INSERT INTO dry_cache_entries (source, type, id, data, expires_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(source, type, id) DO UPDATE SET
+		   data = excluded.data,
+		   expires_at = excluded.expires_at`,
+		key.Source, key.Type, key.Id, []byte(*data), expiresAt)
+
+	return err
+}
+
+func (c *sqliteCache) GetContext(ctx context.Context, key *CacheKey) (*CacheData, error) {
+	data, _, err := c.GetWithExpiry(ctx, key)
+	return data, err
+}
+
+func (c *sqliteCache) GetWithExpiry(ctx context.Context, key *CacheKey) (_ *CacheData, _ time.Time, err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqliteCacheAdapterName, metricOperationGet, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return nil, time.Time{}, sqliteCacheErrBadParams
+	}
+
+	var data []byte
+	var expiresAt int64
+
+	err = c.db.QueryRowContext(ctx,
+		`SELECT data, expires_at FROM dry_cache_entries
+		 WHERE source = ? AND type = ? AND id = ?`,
+		key.Source, key.Type, key.Id).Scan(&data, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, time.Time{}, sqliteCacheErrNonExistent
+		}
+
+		return nil, time.Time{}, err
+	}
+
+	if expiresAt == 0 {
+		cacheData := CacheData(data)
+		return &cacheData, time.Time{}, nil
+	}
+
+	if time.Now().UnixNano() >= expiresAt {
+		_ = c.deleteExpired(ctx, key, expiresAt)
+		return nil, time.Time{}, sqliteCacheErrExpired
+	}
+
+	cacheData := CacheData(data)
+	return &cacheData, time.Unix(0, expiresAt), nil
+}
+
+// deleteExpired deletes an expired entry unless it was refreshed since it
+// was read with expiresAt
+func (c *sqliteCache) deleteExpired(ctx context.Context, key *CacheKey, expiresAt int64) error {
+	_, err := c.db.ExecContext(ctx,
+		`DELETE FROM dry_cache_entries
+		 WHERE source = ? AND type = ? AND id = ? AND expires_at = ?`,
+		key.Source, key.Type, key.Id, expiresAt)
+
+	return err
+}
+
+func (c *sqliteCache) Delete(ctx context.Context, key *CacheKey) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqliteCacheAdapterName, metricOperationDelete, key, start, err)
+	}(time.Now())
+
+	if key == nil {
+		return sqliteCacheErrBadParams
+	}
+
+	_, err = c.db.ExecContext(ctx,
+		`DELETE FROM dry_cache_entries WHERE source = ? AND type = ? AND id = ?`,
+		key.Source, key.Type, key.Id)
+
+	return err
+}
+
+func (c *sqliteCache) Invalidate(ctx context.Context, filter InvalidationFilter) (err error) {
+	defer func(start time.Time) {
+		observeAdapterOperation(sqliteCacheAdapterName, metricOperationInvalidate, nil, start, err)
+	}(time.Now())
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`DELETE FROM dry_cache_entries WHERE source = ?`)
+	args := []any{filter.Source}
+
+	if filter.Type != "" {
+		query.WriteString(` AND type = ?`)
+		args = append(args, filter.Type)
+	}
+
+	// LIKE is case insensitive in SQLite, compare the prefix exactly
+	if filter.IdPrefix != "" {
+		query.WriteString(` AND substr(id, 1, length(?)) = ?`)
+		args = append(args, filter.IdPrefix, filter.IdPrefix)
+	}
+
+	_, err = c.db.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// Close stops the background purger. The underlying database is owned by
+// the localdb.Manager and is not closed. It is safe to call multiple times
+func (c *sqliteCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return nil
+}
+
+func (c *sqliteCache) purger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if _, err := c.purgeExpired(context.Background()); err != nil {
+				log.Warnf("SQLite Cache: Failed to purge expired entries: %v", err)
+			}
+		}
+	}
+}
+
+func (c *sqliteCache) purgeExpired(ctx context.Context) (int64, error) {
+	res, err := c.db.ExecContext(ctx,
+		`DELETE FROM dry_cache_entries WHERE expires_at != 0 AND expires_at <= ?`,
+		time.Now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/safedep/dry/localdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSqliteCache(t *testing.T, dir string) *sqliteCache {
+	t.Helper()
+
+	mgr := localdb.New(localdb.Config{Dir: dir})
+	c, err := NewSqliteCache(context.Background(), SqliteCacheConfig{
+		Manager:       mgr,
+		PurgeInterval: -1,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, c.Close())
+		assert.NoError(t, mgr.Close())
+	})
+
+	return c.(*sqliteCache)
+}
+
+func TestSqliteCacheBadParams(t *testing.T) {
+	_, err := NewSqliteCache(context.Background(), SqliteCacheConfig{})
+	assert.ErrorIs(t, err, sqliteCacheErrBadParams)
+
+	c := newTestSqliteCache(t, t.TempDir())
+	assert.ErrorIs(t, c.Put(nil, &CacheData{}, time.Minute), sqliteCacheErrBadParams)
+
+	_, err = c.Get(nil)
+	assert.ErrorIs(t, err, sqliteCacheErrBadParams)
+}
+
+func TestSqliteCachePutGet(t *testing.T) {
+	c := newTestSqliteCache(t, t.TempDir())
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("v1")
+	require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+
+	data = CacheData("v2")
+	require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+
+	res, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, CacheData("v2"), *res)
+
+	_, err = c.GetContext(ctx, &CacheKey{Source: "npm", Type: "package", Id: "lodash"})
+	assert.ErrorIs(t, err, sqliteCacheErrNonExistent)
+
+	require.NoError(t, c.Delete(ctx, key))
+
+	_, err = c.GetContext(ctx, key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestSqliteCacheExpiry(t *testing.T) {
+	c := newTestSqliteCache(t, t.TempDir())
+	ctx := context.Background()
+	data := CacheData("value")
+
+	expiring := &CacheKey{Source: "s", Type: "t", Id: "expiring"}
+	require.NoError(t, c.PutContext(ctx, expiring, &data, 10*time.Millisecond))
+
+	persistent := &CacheKey{Source: "s", Type: "t", Id: "persistent"}
+	require.NoError(t, c.PutContext(ctx, persistent, &data, 0))
+
+	purged := &CacheKey{Source: "s", Type: "t", Id: "purged"}
+	require.NoError(t, c.PutContext(ctx, purged, &data, 10*time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := c.GetContext(ctx, expiring)
+	assert.ErrorIs(t, err, sqliteCacheErrExpired)
+
+	n, err := c.purgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = c.GetContext(ctx, persistent)
+	assert.NoError(t, err)
+}
+
+func TestSqliteCacheExpiredReadKeepsRefreshedEntry(t *testing.T) {
+	c := newTestSqliteCache(t, t.TempDir())
+	ctx := context.Background()
+	key := &CacheKey{Source: "s", Type: "t", Id: "refreshed"}
+
+	stale := CacheData("stale")
+	require.NoError(t, c.PutContext(ctx, key, &stale, 10*time.Millisecond))
+
+	var expiresAt int64
+	require.NoError(t, c.db.QueryRowContext(ctx,
+		`SELECT expires_at FROM dry_cache_entries WHERE id = ?`, key.Id).Scan(&expiresAt))
+
+	// Refreshed between reading the expired entry and deleting it
+	fresh := CacheData("fresh")
+	require.NoError(t, c.PutContext(ctx, key, &fresh, time.Minute))
+	require.NoError(t, c.deleteExpired(ctx, key, expiresAt))
+
+	res, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, fresh, *res)
+}
+
+func TestSqliteCacheInvalidate(t *testing.T) {
+	c := newTestSqliteCache(t, t.TempDir())
+	ctx := context.Background()
+	data := CacheData("value")
+
+	keys := []*CacheKey{
+		{Source: "npm", Type: "package", Id: "@Angular/core"},
+		{Source: "npm", Type: "package", Id: "@angular/core"},
+		{Source: "npm", Type: "version", Id: "@angular/core"},
+	}
+
+	for _, key := range keys {
+		require.NoError(t, c.PutContext(ctx, key, &data, time.Minute))
+	}
+
+	require.NoError(t, c.Invalidate(ctx, InvalidationFilter{
+		Source:   "npm",
+		Type:     "package",
+		IdPrefix: "@angular/",
+	}))
+
+	// Prefix match is case sensitive
+	_, err := c.GetContext(ctx, keys[0])
+	assert.NoError(t, err)
+
+	_, err = c.GetContext(ctx, keys[1])
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	_, err = c.GetContext(ctx, keys[2])
+	assert.NoError(t, err)
+
+	require.NoError(t, c.Invalidate(ctx, InvalidationFilter{Source: "npm"}))
+
+	for _, key := range keys {
+		_, err := c.GetContext(ctx, key)
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	}
+}
+
+func TestSqliteCachePersistsAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+
+	mgr := localdb.New(localdb.Config{Dir: dir})
+	c, err := NewSqliteCache(context.Background(), SqliteCacheConfig{Manager: mgr})
+	require.NoError(t, err)
+
+	_, err = ThroughContext(context.Background(), c, key, time.Hour,
+		func(_ context.Context) (testCacheValue, error) {
+			return testCacheValue{Name: "express", Count: 1}, nil
+		})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Close())
+	require.NoError(t, mgr.Close())
+
+	c2 := newTestSqliteCache(t, dir)
+	res, err := ThroughContext(context.Background(), c2, key, time.Hour,
+		func(_ context.Context) (testCacheValue, error) {
+			t.Fatal("must be served from cache")
+			return testCacheValue{}, nil
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, testCacheValue{Name: "express", Count: 1}, res)
+}
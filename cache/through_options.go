@@ -0,0 +1,77 @@
+package cache
+
+import "time"
+
+// ThroughOption customizes the behavior of Through and ThroughContext
+type ThroughOption func(*throughConfig)
+
+type throughConfig struct {
+	staleTTL    time.Duration
+	notFoundErr error
+	negativeTTL time.Duration
+	codec       Codec
+	compression Compression
+}
+
+func newThroughConfig(opts []ThroughOption) throughConfig {
+	config := throughConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.codec == nil {
+		config.codec = JsonCodec()
+	}
+
+	return config
+}
+
+// WithStaleWhileRevalidate keeps entries for staleTTL beyond their ttl.
+// An expired entry within this window is served to the caller while it is
+// refreshed in the background
+func WithStaleWhileRevalidate(staleTTL time.Duration) ThroughOption {
+	return func(c *throughConfig) {
+		c.staleTTL = staleTTL
+	}
+}
+
+// WithNegativeCaching caches failures of the cacheable function matching
+// notFoundErr (using errors.Is) for ttl. Subsequent lookups of the key
+// return notFoundErr without invoking the function until the entry expires
+func WithNegativeCaching(notFoundErr error, ttl time.Duration) ThroughOption {
+	return func(c *throughConfig) {
+		c.notFoundErr = notFoundErr
+		c.negativeTTL = ttl
+	}
+}
+
+// WithCodec sets the codec used to serialize values. Defaults to JSON.
+// Entries are always decoded using the codec recorded in their header
+func WithCodec(codec Codec) ThroughOption {
+	return func(c *throughConfig) {
+		c.codec = codec
+	}
+}
+
+// WithCompression sets the compression applied to serialized values.
+// Defaults to no compression
+func WithCompression(compression Compression) ThroughOption {
+	return func(c *throughConfig) {
+		c.compression = compression
+	}
+}
+
+// requiresHeader is true when entries written with this config can not be
+// represented as plain JSON understood by older readers
+func (c *throughConfig) requiresHeader() bool {
+	return c.staleTTL > 0 || c.codec.ID() != CodecIdJson || c.compression != CompressionNone
+}
+
+// codecFor returns the codec to decode an entry written with id
+func (c *throughConfig) codecFor(id CodecID) (Codec, error) {
+	if c.codec.ID() == id {
+		return c.codec, nil
+	}
+
+	return codecByID(id)
+}
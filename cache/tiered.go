@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/safedep/dry/log"
+)
+
+const (
+	tieredCacheDefaultL1TTL = time.Minute
+)
+
+var tieredCacheErrBadParams = errors.New("bad params")
+
+// TieredCacheConfig configures a two level cache
+type TieredCacheConfig struct {
+	// Fast, usually in-process, cache consulted first
+	L1 CacheAdapter
+
+	// Slower, usually shared, cache consulted on L1 miss
+	L2 CacheAdapter
+
+	// Upper bound for the ttl of L1 entries. It is also used as the ttl of
+	// entries populated from an L2 that does not implement ExpiryAwareCache.
+	// Defaults to 1 minute
+	L1TTL time.Duration
+}
+
+type tieredCache struct {
+	config TieredCacheConfig
+}
+
+// NewTieredCache returns a cache that layers L1 in front of L2. Entries
+// found in L2 are populated in L1 for the remaining L2 ttl, bounded by
+// L1TTL. Writes, deletes and invalidations are applied to both tiers. The
+// tiers are owned by the caller and are not closed by the tiered cache
+func NewTieredCache(config TieredCacheConfig) (CacheAdapter, error) {
+	if config.L1 == nil || config.L2 == nil || config.L1TTL < 0 {
+		return nil, tieredCacheErrBadParams
+	}
+
+	if config.L1TTL == 0 {
+		config.L1TTL = tieredCacheDefaultL1TTL
+	}
+
+	return &tieredCache{config: config}, nil
+}
+
+func (c *tieredCache) Put(key *CacheKey, data *CacheData, ttl time.Duration) error {
+	return c.PutContext(context.Background(), key, data, ttl)
+}
+
+func (c *tieredCache) Get(key *CacheKey) (*CacheData, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// PutContext writes to L2 first so that L1 never holds an entry that
+// failed to reach the shared tier
+func (c *tieredCache) PutContext(ctx context.Context, key *CacheKey, data *CacheData, ttl time.Duration) error {
+	if err := c.config.L2.PutContext(ctx, key, data, ttl); err != nil {
+		return err
+	}
+
+	if err := c.config.L1.PutContext(ctx, key, data, c.l1TTL(ttl)); err != nil {
+		log.Debugf("Tiered Cache: Failed to put in L1 due to: %v", err)
+	}
+
+	return nil
+}
+
+func (c *tieredCache) GetContext(ctx context.Context, key *CacheKey) (*CacheData, error) {
+	data, err := c.config.L1.GetContext(ctx, key)
+	if err == nil {
+		return data, nil
+	}
+
+	var expiresAt time.Time
+	if l2, ok := c.config.L2.(ExpiryAwareCache); ok {
+		data, expiresAt, err = l2.GetWithExpiry(ctx, key)
+	} else {
+		data, err = c.config.L2.GetContext(ctx, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := c.config.L1TTL
+	if !expiresAt.IsZero() {
+		remaining := time.Until(expiresAt)
+
+		// Entry is about to expire in L2, avoid populating L1
+		if remaining <= 0 {
+			return data, nil
+		}
+
+		ttl = c.l1TTL(remaining)
+	}
+
+	if err := c.config.L1.PutContext(ctx, key, data, ttl); err != nil {
+		log.Debugf("Tiered Cache: Failed to populate L1 due to: %v", err)
+	}
+
+	return data, nil
+}
+
+func (c *tieredCache) Delete(ctx context.Context, key *CacheKey) error {
+	return errors.Join(c.config.L1.Delete(ctx, key),
+		c.config.L2.Delete(ctx, key))
+}
+
+func (c *tieredCache) Invalidate(ctx context.Context, filter InvalidationFilter) error {
+	return errors.Join(c.config.L1.Invalidate(ctx, filter),
+		c.config.L2.Invalidate(ctx, filter))
+}
+
+// l1TTL bounds ttl by the configured L1TTL. A ttl <= 0 means the entry
+// never expires in L2, it is still bounded in L1
+func (c *tieredCache) l1TTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > c.config.L1TTL {
+		return c.config.L1TTL
+	}
+
+	return ttl
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTieredCache(t *testing.T, l1TTL time.Duration) (*tieredCache, *boundedMemoryCache, CacheAdapter) {
+	t.Helper()
+
+	l1 := newTestMemoryCache(t, MemoryCacheConfig{JanitorInterval: -1})
+	l2 := newTestMemoryCache(t, MemoryCacheConfig{JanitorInterval: -1})
+
+	c, err := NewTieredCache(TieredCacheConfig{L1: l1, L2: l2, L1TTL: l1TTL})
+	require.NoError(t, err)
+
+	return c.(*tieredCache), l1, l2
+}
+
+func TestTieredCacheBadParams(t *testing.T) {
+	_, err := NewTieredCache(TieredCacheConfig{L1: NewUnsafeMemoryCache()})
+	assert.ErrorIs(t, err, tieredCacheErrBadParams)
+}
+
+func TestTieredCachePutWritesBothTiers(t *testing.T) {
+	c, l1, l2 := newTestTieredCache(t, time.Minute)
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("value")
+	require.NoError(t, c.PutContext(ctx, key, &data, time.Hour))
+
+	_, l1Expiry, err := l1.GetWithExpiry(ctx, key)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), l1Expiry, time.Second)
+
+	_, err = l2.GetContext(ctx, key)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Delete(ctx, key))
+
+	_, err = l1.GetContext(ctx, key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	_, err = l2.GetContext(ctx, key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestTieredCachePopulatesL1WithRemainingTTL(t *testing.T) {
+	c, l1, l2 := newTestTieredCache(t, time.Hour)
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("value")
+	require.NoError(t, l2.PutContext(ctx, key, &data, 10*time.Minute))
+
+	res, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, data, *res)
+
+	_, l1Expiry, err := l1.GetWithExpiry(ctx, key)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), l1Expiry, time.Second)
+
+	// Served from L1 once populated
+	require.NoError(t, l2.Delete(ctx, key))
+
+	_, err = c.GetContext(ctx, key)
+	assert.NoError(t, err)
+}
+
+func TestTieredCacheBoundsNonExpiringEntries(t *testing.T) {
+	c, l1, l2 := newTestTieredCache(t, time.Minute)
+	ctx := context.Background()
+
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("value")
+	require.NoError(t, l2.PutContext(ctx, key, &data, 0))
+
+	_, err := c.GetContext(ctx, key)
+	require.NoError(t, err)
+
+	_, l1Expiry, err := l1.GetWithExpiry(ctx, key)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), l1Expiry, time.Second)
+}
+
+func TestTieredCacheWithoutExpiryAwareL2(t *testing.T) {
+	l1 := newTestMemoryCache(t, MemoryCacheConfig{JanitorInterval: -1})
+	l2 := NewUnsafeMemoryCache()
+
+	c, err := NewTieredCache(TieredCacheConfig{L1: l1, L2: l2, L1TTL: 5 * time.Minute})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := &CacheKey{Source: "npm", Type: "package", Id: "express"}
+	data := CacheData("value")
+	require.NoError(t, l2.PutContext(ctx, key, &data, time.Hour))
+
+	_, err = c.GetContext(ctx, key)
+	require.NoError(t, err)
+
+	_, l1Expiry, err := l1.GetWithExpiry(ctx, key)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), l1Expiry, time.Second)
+
+	require.NoError(t, c.Invalidate(ctx, InvalidationFilter{Source: "npm"}))
+
+	_, err = c.GetContext(ctx, key)
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
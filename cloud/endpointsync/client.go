@@ -13,7 +13,7 @@ import (
 	servicev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/services/controltower/v1"
 	"github.com/google/uuid"
 	"github.com/safedep/dry/log"
-	gobreaker "github.com/sony/gobreaker/v2"
+	"github.com/safedep/dry/retry"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -34,7 +34,7 @@ type SyncClient struct {
 	identity    *controltowerv1.EndpointIdentity
 	config      *syncConfig
 	store       *wal
-	breaker     *gobreaker.CircuitBreaker[*servicev1.SyncEventsResponse]
+	breaker     retry.CircuitBreaker
 }
 
 // NewSyncClient creates a new sync client.
@@ -81,16 +81,11 @@ func NewSyncClient(toolName string, toolVersion string, transport EventTransport
 	}
 	store.maxPending = cfg.maxPending
 
-	breaker := gobreaker.NewCircuitBreaker[*servicev1.SyncEventsResponse](gobreaker.Settings{
-		Name:        fmt.Sprintf("endpointsync-%s", toolName),
-		MaxRequests: 1,
-		Timeout:     5 * time.Minute,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			return counts.ConsecutiveFailures >= 5
-		},
-		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
-			log.Infof("Circuit breaker %s: %s -> %s", name, from, to)
-		},
+	breaker := retry.NewCircuitBreaker(retry.CircuitBreakerConfig{
+		Name:                fmt.Sprintf("endpointsync-%s", toolName),
+		ConsecutiveFailures: 5,
+		OpenTimeout:         5 * time.Minute,
+		MaxHalfOpenRequests: 1,
 	})
 
 	return &SyncClient{
@@ -187,7 +182,7 @@ func (c *SyncClient) Sync(ctx context.Context) (int, error) {
 			Events:   toolEvents,
 		}
 
-		resp, err := c.breaker.Execute(func() (*servicev1.SyncEventsResponse, error) {
+		resp, err := retry.ExecuteWithBreaker(ctx, c.breaker, func(ctx context.Context) (*servicev1.SyncEventsResponse, error) {
 			return c.transport.Send(ctx, req)
 		})
 		if err != nil {
@@ -0,0 +1,39 @@
+package destinations
+
+import (
+	"context"
+
+	"github.com/safedep/dry/events"
+	"github.com/safedep/dry/events/outbox"
+	"github.com/safedep/dry/retry"
+)
+
+// CircuitBreakerDestination publishes through a retry.CircuitBreaker so that a
+// failing transport is not called for every pending delivery. While the breaker
+// is open Publish fails fast with retry.ErrCircuitOpen and the outbox records
+// the delivery as failed, to be retried by a later drain.
+type CircuitBreakerDestination struct {
+	dest    outbox.Destination
+	breaker retry.CircuitBreaker
+}
+
+var _ outbox.Destination = (*CircuitBreakerDestination)(nil)
+
+// NewCircuitBreakerDestination wraps dest with breaker. The breaker can be
+// shared with other clients of the same upstream. Name and Accepts are those of
+// dest, so wrapping an existing destination does not strand its deliveries.
+func NewCircuitBreakerDestination(dest outbox.Destination, breaker retry.CircuitBreaker) *CircuitBreakerDestination {
+	return &CircuitBreakerDestination{dest: dest, breaker: breaker}
+}
+
+func (d *CircuitBreakerDestination) Name() string { return d.dest.Name() }
+
+func (d *CircuitBreakerDestination) Accepts(routing events.Routing) bool {
+	return d.dest.Accepts(routing)
+}
+
+func (d *CircuitBreakerDestination) Publish(ctx context.Context, req outbox.PublishRequest) error {
+	return d.breaker.Execute(ctx, func(ctx context.Context) error {
+		return d.dest.Publish(ctx, req)
+	})
+}
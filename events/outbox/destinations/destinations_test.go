@@ -1,9 +1,13 @@
 package destinations
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/safedep/dry/events"
+	"github.com/safedep/dry/events/outbox"
+	"github.com/safedep/dry/retry"
 	"github.com/safedep/dry/stream"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,3 +28,37 @@ func TestS2Destination_Accepts(t *testing.T) {
 	assert.True(t, d.Accepts(events.Routing{Exposure: events.ExposurePublic}))
 	assert.True(t, d.Accepts(events.Routing{Exposure: events.ExposurePrivate}))
 }
+
+type failingPublisher struct {
+	calls int
+}
+
+func (p *failingPublisher) Publish(_ context.Context, _ string, _ []byte) error {
+	p.calls++
+	return errors.New("nats unavailable")
+}
+
+func TestCircuitBreakerDestination(t *testing.T) {
+	pub := &failingPublisher{}
+	d := NewCircuitBreakerDestination(NewNATS(pub), retry.NewCircuitBreaker(retry.CircuitBreakerConfig{
+		Name:                "outbox-nats-test",
+		ConsecutiveFailures: 2,
+	}))
+
+	private := events.Routing{Exposure: events.ExposurePrivate, Domain: "packageregistry", Major: 1, Message: "PackageVersionObservationEvent"}
+	public := events.Routing{Exposure: events.ExposurePublic, Domain: "threatintel", Major: 1, Message: "VerdictsEvent"}
+
+	// Name and eligibility are those of the wrapped destination
+	assert.Equal(t, "nats", d.Name())
+	assert.True(t, d.Accepts(private))
+	assert.False(t, d.Accepts(public))
+
+	req := outbox.PublishRequest{Routing: private, EventID: "01J0000000000000000000000", Record: []byte("event")}
+	for i := 0; i < 2; i++ {
+		assert.Error(t, d.Publish(context.Background(), req))
+	}
+
+	// The open breaker fails fast without calling the transport
+	assert.ErrorIs(t, d.Publish(context.Background(), req), retry.ErrCircuitOpen)
+	assert.Equal(t, 2, pub.calls)
+}
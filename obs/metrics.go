@@ -32,6 +32,11 @@ type Histogram interface {
 	Observe(float64)
 }
 
+// HistogramVec is a histogram metric parameterized by labels.
+type HistogramVec interface {
+	WithLabels(map[string]string) Histogram
+}
+
 type ProviderSpecificOpts interface{}
 type ProviderSpecificOptsEditor func(ProviderSpecificOpts)
 
@@ -45,6 +50,13 @@ type Provider interface {
 	NewGaugeVec(name, desc string, labels []string) GaugeVec
 }
 
+// HistogramVecProvider is an optional interface of a Provider that supports
+// histograms parameterized by labels. Histograms of providers that do not
+// implement it discard observations.
+type HistogramVecProvider interface {
+	NewHistogramVec(name, desc string, labels []string, opts ...ProviderSpecificOptsEditor) HistogramVec
+}
+
 type dummyReceiver struct{}
 
 func (d *dummyReceiver) Inc()                                 {}
@@ -61,6 +73,12 @@ type dummyGaugeVecReceiver struct{}
 
 func (d *dummyGaugeVecReceiver) WithLabels(map[string]string) Gauge { return &dummyReceiver{} }
 
+// dummyHistogramVecReceiver exists separately for the same reason as
+// dummyGaugeVecReceiver.
+type dummyHistogramVecReceiver struct{}
+
+func (d *dummyHistogramVecReceiver) WithLabels(map[string]string) Histogram { return &dummyReceiver{} }
+
 type dummyProvider struct{}
 
 // NewCounter creates a new Counter.
@@ -88,8 +106,15 @@ func (d *dummyProvider) NewGaugeVec(_, _ string, _ []string) GaugeVec {
 	return &dummyGaugeVecReceiver{}
 }
 
+// NewHistogramVec creates a new HistogramVec.
+func (d *dummyProvider) NewHistogramVec(_, _ string, _ []string, _ ...ProviderSpecificOptsEditor) HistogramVec {
+	return &dummyHistogramVecReceiver{}
+}
+
 var (
 	__provider Provider = &dummyProvider{}
+
+	_ HistogramVecProvider = (*dummyProvider)(nil)
 )
 
 func NewCounter(name, desc string) Counter {
@@ -112,6 +137,15 @@ func NewHistogram(name, desc string) Histogram {
 	return __provider.NewHistogram(name, desc)
 }
 
+func NewHistogramVec(name, desc string, labels []string) HistogramVec {
+	provider, ok := __provider.(HistogramVecProvider)
+	if !ok {
+		return &dummyHistogramVecReceiver{}
+	}
+
+	return provider.NewHistogramVec(name, desc, labels)
+}
+
 // InitPrometheusMetricsProvider initializes the default metrics provider to
 // use Prometheus Go SDK. This function is not thread-safe and should be called
 // before any other function in this package.
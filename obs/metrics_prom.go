@@ -59,14 +59,26 @@ type prometheusMetricsProvider struct {
 	subsystem string
 }
 
+var _ HistogramVecProvider = (*prometheusMetricsProvider)(nil)
+
 type promHistogramReceiver struct {
-	histogram prometheus.Histogram
+	histogram prometheus.Observer
 }
 
 func (r *promHistogramReceiver) Observe(value float64) {
 	r.histogram.Observe(value)
 }
 
+type promHistogramVecReceiver struct {
+	histogram *prometheus.HistogramVec
+}
+
+func (r *promHistogramVecReceiver) WithLabels(labels map[string]string) Histogram {
+	return &promHistogramReceiver{
+		histogram: r.histogram.With(labels),
+	}
+}
+
 // NewPrometheusMetricsProvider creates a new Provider that uses Prometheus
 // Go SDK to create metrics.
 func NewPrometheusMetricsProvider(namespace, subsystem string) Provider {
@@ -169,3 +181,28 @@ func (p *prometheusMetricsProvider) NewHistogram(name, desc string,
 		histogram: prometheus.NewHistogram(histogramOpts),
 	}
 }
+
+func (p *prometheusMetricsProvider) NewHistogramVec(name, desc string, labels []string,
+	opts ...ProviderSpecificOptsEditor) HistogramVec {
+	histogramOpts := prometheus.HistogramOpts{
+		Namespace: p.namespace,
+		Subsystem: p.subsystem,
+		Name:      name,
+		Help:      desc,
+		ConstLabels: prometheus.Labels{
+			"service": AppServiceName("app"),
+			"env":     AppServiceEnv("dev"),
+		},
+	}
+
+	for _, editor := range opts {
+		editor(&histogramOpts)
+	}
+
+	h := prometheus.NewHistogramVec(histogramOpts, labels)
+
+	prometheus.MustRegister(h)
+	return &promHistogramVecReceiver{
+		histogram: h,
+	}
+}
@@ -34,3 +34,14 @@ func TestPrometheusGaugeVec(t *testing.T) {
 	g.WithLabels(map[string]string{"label1": "1", "label2": "2"}).Add(1)
 	g.WithLabels(map[string]string{"label1": "1", "label2": "2"}).Sub(1)
 }
+
+func TestPrometheusHistogramVec(t *testing.T) {
+	p, ok := NewPrometheusMetricsProvider("test", "test").(HistogramVecProvider)
+	if !ok {
+		t.Fatal("prometheus provider does not support histogram vectors")
+	}
+
+	h := p.NewHistogramVec("test_histogram_vec_1", "test", []string{"label1", "label2"})
+
+	h.WithLabels(map[string]string{"label1": "1", "label2": "2"}).Observe(1)
+}
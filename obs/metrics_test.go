@@ -12,3 +12,18 @@ func TestDefaultMetricsProviderGaugeVec(t *testing.T) {
 	gv := NewGaugeVec("test", "test", []string{"label1"})
 	gv.WithLabels(map[string]string{"label1": "1"}).Set(1)
 }
+
+func TestDefaultMetricsProviderHistogramVec(t *testing.T) {
+	hv := NewHistogramVec("test", "test", []string{"label1"})
+	hv.WithLabels(map[string]string{"label1": "1"}).Observe(1)
+}
+
+func TestHistogramVecWithoutProviderSupport(t *testing.T) {
+	defer func(provider Provider) { __provider = provider }(__provider)
+
+	// Embedding the interface hides the optional HistogramVecProvider
+	__provider = struct{ Provider }{&dummyProvider{}}
+
+	hv := NewHistogramVec("test", "test", []string{"label1"})
+	hv.WithLabels(map[string]string{"label1": "1"}).Observe(1)
+}
@@ -1,6 +1,7 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,35 +9,61 @@ import (
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 )
 
-type cratesAdapter struct{}
-type cratesPublisherDiscovery struct{}
-type cratesPackageDiscovery struct{}
+type cratesAdapter struct {
+	registry registryEndpoint
+}
+
+type cratesPublisherDiscovery struct {
+	registry registryEndpoint
+}
+
+type cratesPackageDiscovery struct {
+	registry registryEndpoint
+}
 
 // Verify that cratesAdapter implements the Client interface
 var _ Client = (*cratesAdapter)(nil)
 
+var (
+	_ ContextPublisherDiscovery = (*cratesPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*cratesPackageDiscovery)(nil)
+)
+
 // NewCratesAdapter creates a new Crates.io registry adapter
 func NewCratesAdapter() (Client, error) {
-	return &cratesAdapter{}, nil
+	return newCratesAdapter(&RegistryAdapterConfig{})
+}
+
+func newCratesAdapter(config *RegistryAdapterConfig) (Client, error) {
+	registry, err := newRegistryEndpoint(config, config.Crates, cratesDefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cratesAdapter{registry: registry}, nil
 }
 
 func (ca *cratesAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
-	return &cratesPublisherDiscovery{}, nil
+	return &cratesPublisherDiscovery{registry: ca.registry}, nil
 }
 
 func (ca *cratesAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &cratesPackageDiscovery{}, nil
+	return &cratesPackageDiscovery{registry: ca.registry}, nil
 }
 
 // GetPackagePublisher returns the publishers of a package
 func (cp *cratesPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return cp.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (cp *cratesPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	packageName := packageVersion.GetPackage().GetName()
 
-	url := cratesAPIEndpointPackageSearchWithOwners(packageName)
+	url := cratesAPIEndpointPackageSearchWithOwners(cp.registry.baseURL, packageName)
 
-	res, err := httpClient().Get(url)
+	res, err := cp.registry.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -72,6 +99,10 @@ func (cp *cratesPublisherDiscovery) GetPackagePublisher(packageVersion *packagev
 
 // GetPublisherPackages returns all packages published by a given publisher
 func (cp *cratesPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
+	return cp.GetPublisherPackagesContext(context.Background(), publisher)
+}
+
+func (cp *cratesPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
 	if publisher.ID == 0 {
 		return nil, ErrAuthorNotFound
 	}
@@ -89,10 +120,10 @@ func (cp *cratesPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([
 
 	for query != "" && page < MAX_PAGES {
 		// The crates API provides the query separator "?" in the `next_page` field
-		url := cratesAPIEndpointPackageWithQuery(query)
-		res, err := httpClient().Get(url)
+		url := cratesAPIEndpointPackageWithQuery(cp.registry.baseURL, query)
+		res, err := cp.registry.get(ctx, url)
 		if err != nil {
-			return nil, ErrFailedToFetchPackage
+			return nil, err
 		}
 
 		if res.StatusCode != http.StatusOK {
@@ -123,7 +154,7 @@ func (cp *cratesPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([
 
 	packages := make([]*Package, len(allSearchResults))
 	for i, crate := range allSearchResults {
-		pkg, err := cratesGetPackageDetails(crate.Name)
+		pkg, err := cratesGetPackageDetails(ctx, cp.registry, crate.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -134,15 +165,23 @@ func (cp *cratesPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([
 }
 
 func (cp *cratesPackageDiscovery) GetPackage(packageName string) (*Package, error) {
-	return cratesGetPackageDetails(packageName)
+	return cp.GetPackageContext(context.Background(), packageName)
+}
+
+func (cp *cratesPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
+	return cratesGetPackageDetails(ctx, cp.registry, packageName)
 }
 
 func (cp *cratesPackageDiscovery) GetPackageDependencies(packageName, packageVersion string) (*PackageDependencyList, error) {
-	url := cratesAPIEndpointPackageDependencies(packageName, packageVersion)
+	return cp.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
 
-	res, err := httpClient().Get(url)
+func (cp *cratesPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName, packageVersion string) (*PackageDependencyList, error) {
+	url := cratesAPIEndpointPackageDependencies(cp.registry.baseURL, packageName, packageVersion)
+
+	res, err := cp.registry.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -184,8 +223,12 @@ func (cp *cratesPackageDiscovery) GetPackageDependencies(packageName, packageVer
 }
 
 func (cp *cratesPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return cp.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (cp *cratesPackageDiscovery) GetPackageDownloadStatsContext(ctx context.Context, packageName string) (DownloadStats, error) {
 	// Get the package to extract download counts
-	pkg, err := cratesGetPackageDetails(packageName)
+	pkg, err := cratesGetPackageDetails(ctx, cp.registry, packageName)
 	if err != nil {
 		return DownloadStats{}, err
 	}
@@ -206,12 +249,12 @@ func (cp *cratesPackageDiscovery) GetPackageDownloadStats(packageName string) (D
 	}, nil
 }
 
-func cratesGetPackageDetails(packageName string) (*Package, error) {
-	pkgUrl := cratesAPIEndpointPackageURL(packageName)
+func cratesGetPackageDetails(ctx context.Context, registry registryEndpoint, packageName string) (*Package, error) {
+	pkgUrl := cratesAPIEndpointPackageURL(registry.baseURL, packageName)
 
-	res, err := httpClient().Get(pkgUrl)
+	res, err := registry.get(ctx, pkgUrl)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -239,8 +282,8 @@ func cratesGetPackageDetails(packageName string) (*Package, error) {
 		}
 	}
 
-	ownersUrl := cratesAPIEndpointPackageSearchWithOwners(packageName)
-	ownersRes, err := httpClient().Get(ownersUrl)
+	ownersUrl := cratesAPIEndpointPackageSearchWithOwners(registry.baseURL, packageName)
+	ownersRes, err := registry.get(ctx, ownersUrl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch owners for %s: %w", packageName, err)
 	}
@@ -5,25 +5,25 @@ import "fmt"
 // Crates API Endpoints
 // https://doc.rust-lang.org/cargo/reference/registry-index.html#index-format
 
-var cratesBaseURL = "https://crates.io/api/v1"
+const cratesDefaultBaseURL = "https://crates.io/api/v1"
 
-func cratesAPIEndpointPackageURL(packageName string) string {
-	return fmt.Sprintf("%s/crates/%s", cratesBaseURL, packageName)
+func cratesAPIEndpointPackageURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/crates/%s", baseURL, packageName)
 }
 
-func cratesAPIEndpointPackageWithVersionURL(packageName, version string) string {
-	return fmt.Sprintf("%s/crates/%s/%s", cratesBaseURL, packageName, version)
+func cratesAPIEndpointPackageWithVersionURL(baseURL, packageName, version string) string {
+	return fmt.Sprintf("%s/crates/%s/%s", baseURL, packageName, version)
 }
 
-func cratesAPIEndpointPackageDependencies(packageName, version string) string {
-	return fmt.Sprintf("%s/dependencies", cratesAPIEndpointPackageWithVersionURL(packageName, version))
+func cratesAPIEndpointPackageDependencies(baseURL, packageName, version string) string {
+	return fmt.Sprintf("%s/dependencies", cratesAPIEndpointPackageWithVersionURL(baseURL, packageName, version))
 }
 
-func cratesAPIEndpointPackageSearchWithOwners(packageName string) string {
-	return fmt.Sprintf("%s/owners", cratesAPIEndpointPackageURL(packageName))
+func cratesAPIEndpointPackageSearchWithOwners(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/owners", cratesAPIEndpointPackageURL(baseURL, packageName))
 }
 
 // The `query` parameter should include the query separator `?`
-func cratesAPIEndpointPackageWithQuery(query string) string {
-	return fmt.Sprintf("%s/crates%s", cratesBaseURL, query)
+func cratesAPIEndpointPackageWithQuery(baseURL, query string) string {
+	return fmt.Sprintf("%s/crates%s", baseURL, query)
 }
@@ -28,6 +28,11 @@ type githubPackageRegistryPackageDiscovery struct {
 // Verify that githubPackageRegistryAdapter implements the Client interface
 var _ Client = (*githubPackageRegistryAdapter)(nil)
 
+var (
+	_ ContextPublisherDiscovery = (*githubPackageRegistryPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*githubPackageRegistryPackageDiscovery)(nil)
+)
+
 // NewGithubPackageRegistryAdapter creates a new GitHub package registry adapter
 func NewGithubPackageRegistryAdapter(gitHubClient *adapters.GithubClient) (Client, error) {
 	return &githubPackageRegistryAdapter{gitHubClient: gitHubClient}, nil
@@ -42,8 +47,10 @@ func (ga *githubPackageRegistryAdapter) PackageDiscovery() (PackageDiscovery, er
 }
 
 func (ga *githubPackageRegistryPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
-	ctx := context.Background()
+	return ga.GetPackagePublisherContext(context.Background(), packageVersion)
+}
 
+func (ga *githubPackageRegistryPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	pkgName := packageVersion.Package.GetName()
 
 	tokens := strings.Split(pkgName, "/")
@@ -56,10 +63,7 @@ func (ga *githubPackageRegistryPublisherDiscovery) GetPackagePublisher(packageVe
 
 	repository, _, err := ga.gitHubClient.Client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
-		if isGitHubRateLimitError(err) {
-			return nil, ErrGitHubRateLimitExceeded
-		}
-		return nil, ErrNoPackagesFound
+		return nil, gitHubRequestError(ctx, err)
 	}
 
 	publisher := []Publisher{}
@@ -78,14 +82,13 @@ func (ga *githubPackageRegistryPublisherDiscovery) GetPackagePublisher(packageVe
 // GetPublisherPackages returns all packages for a publisher
 // Currently, we don't fetch the latest version and package versions
 func (ga *githubPackageRegistryPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
-	ctx := context.Background()
+	return ga.GetPublisherPackagesContext(context.Background(), publisher)
+}
 
+func (ga *githubPackageRegistryPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
 	repos, _, err := ga.gitHubClient.Client.Repositories.ListByUser(ctx, publisher.Name, nil)
 	if err != nil {
-		if isGitHubRateLimitError(err) {
-			return nil, ErrGitHubRateLimitExceeded
-		}
-		return nil, ErrNoPackagesFound
+		return nil, gitHubRequestError(ctx, err)
 	}
 
 	packages := []*Package{}
@@ -101,6 +104,11 @@ func (ga *githubPackageRegistryPublisherDiscovery) GetPublisherPackages(publishe
 }
 
 func (ga *githubPackageRegistryPackageDiscovery) GetPackageDependencies(packageName string,
+	packageVersion string) (*PackageDependencyList, error) {
+	return ga.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+func (ga *githubPackageRegistryPackageDiscovery) GetPackageDependenciesContext(_ context.Context, packageName string,
 	packageVersion string) (*PackageDependencyList, error) {
 	return nil, fmt.Errorf("dependency resolution is not supported for GitHub adapter")
 }
@@ -108,8 +116,10 @@ func (ga *githubPackageRegistryPackageDiscovery) GetPackageDependencies(packageN
 // GetPackage returns the package details from the package name
 // For GitHub the package name is the {owner}/{repo}
 func (ga *githubPackageRegistryPackageDiscovery) GetPackage(packageName string) (*Package, error) {
-	ctx := context.Background()
+	return ga.GetPackageContext(context.Background(), packageName)
+}
 
+func (ga *githubPackageRegistryPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
 	tokens := strings.Split(packageName, "/")
 	if len(tokens) != 2 {
 		return nil, ErrNoPackagesFound
@@ -120,10 +130,7 @@ func (ga *githubPackageRegistryPackageDiscovery) GetPackage(packageName string)
 
 	repository, _, err := ga.gitHubClient.Client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
-		if isGitHubRateLimitError(err) {
-			return nil, ErrGitHubRateLimitExceeded
-		}
-		return nil, ErrNoPackagesFound
+		return nil, gitHubRequestError(ctx, err)
 	}
 
 	latestVersion, err := getGitHubRepositoryLatestVersion(ctx, ga.gitHubClient, repository)
@@ -140,6 +147,10 @@ func (ga *githubPackageRegistryPackageDiscovery) GetPackage(packageName string)
 }
 
 func (ga *githubPackageRegistryPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return ga.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (ga *githubPackageRegistryPackageDiscovery) GetPackageDownloadStatsContext(_ context.Context, packageName string) (DownloadStats, error) {
 	return DownloadStats{}, fmt.Errorf("download stats are not supported for GitHub adapter")
 }
 
@@ -152,6 +163,10 @@ func getGitHubRepositoryLatestVersion(ctx context.Context, ghClient *adapters.Gi
 		return "", ErrGitHubRateLimitExceeded
 	}
 
+	if err != nil && ctx.Err() != nil {
+		return "", fmt.Errorf("%w: %w", ErrFailedToFetchPackage, ctx.Err())
+	}
+
 	if latestRelease != nil && latestRelease.GetTagName() != "" {
 		return latestRelease.GetTagName(), nil
 	}
@@ -164,10 +179,7 @@ func getGitHubRepositoryVersions(ctx context.Context, ghClient *adapters.GithubC
 
 	releases, _, err := ghClient.Client.Repositories.ListReleases(ctx, repo.GetOwner().GetLogin(), repo.GetName(), nil)
 	if err != nil {
-		if isGitHubRateLimitError(err) {
-			return nil, ErrGitHubRateLimitExceeded
-		}
-		return nil, ErrNoPackagesFound
+		return nil, gitHubRequestError(ctx, err)
 	}
 
 	for _, release := range releases {
@@ -199,6 +211,20 @@ func githubRegistryCreatePackageWrapper(repo *github.Repository, latestVersion s
 	return pkg
 }
 
+// gitHubRequestError maps an error of the GitHub API. Requests aborted by ctx
+// are reported as such instead of as missing packages
+func gitHubRequestError(ctx context.Context, err error) error {
+	if isGitHubRateLimitError(err) {
+		return ErrGitHubRateLimitExceeded
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFetchPackage, ctx.Err())
+	}
+
+	return ErrNoPackagesFound
+}
+
 // Check if the error is due to rate limiting
 func isGitHubRateLimitError(err error) bool {
 	_, isRateLimit := err.(*github.RateLimitError)
@@ -1,6 +1,7 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -10,16 +11,36 @@ import (
 	"golang.org/x/mod/modfile"
 )
 
-type goAdapter struct{}
+type goAdapter struct {
+	proxies goProxyList
+}
+
 type goPublisherDiscovery struct{}
-type goPackageDiscovery struct{}
+
+type goPackageDiscovery struct {
+	proxies goProxyList
+}
 
 // Verify that goAdapter implements the Client interface
 var _ Client = (*goAdapter)(nil)
 
+var (
+	_ ContextPublisherDiscovery = goPublisherDiscovery{}
+	_ ContextPackageDiscovery   = goPackageDiscovery{}
+)
+
 // NewGoAdapter creates a new Go registry adapter
 func NewGoAdapter() (Client, error) {
-	return &goAdapter{}, nil
+	return newGoAdapter(&RegistryAdapterConfig{})
+}
+
+func newGoAdapter(config *RegistryAdapterConfig) (Client, error) {
+	proxies, err := newGoProxyList(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &goAdapter{proxies: proxies}, nil
 }
 
 func (na *goAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
@@ -27,23 +48,35 @@ func (na *goAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
 }
 
 func (na *goAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &goPackageDiscovery{}, nil
+	return &goPackageDiscovery{proxies: na.proxies}, nil
 }
 
 func (g goPublisherDiscovery) GetPackagePublisher(_ *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	return nil, ErrOperationNotSupported
 }
 
+func (g goPublisherDiscovery) GetPackagePublisherContext(_ context.Context, _ *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return nil, ErrOperationNotSupported
+}
+
 func (g goPublisherDiscovery) GetPublisherPackages(_ Publisher) ([]*Package, error) {
 	return nil, ErrOperationNotSupported
 }
 
+func (g goPublisherDiscovery) GetPublisherPackagesContext(_ context.Context, _ Publisher) ([]*Package, error) {
+	return nil, ErrOperationNotSupported
+}
+
 func (g goPackageDiscovery) GetPackage(packageName string) (*Package, error) {
+	return g.GetPackageContext(context.Background(), packageName)
+}
+
+func (g goPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
 	url := goProxyAPIEndpointPackageLatestVersionURL(packageName)
 
-	res, err := httpClient().Get(url)
+	res, err := g.proxies.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -60,7 +93,7 @@ func (g goPackageDiscovery) GetPackage(packageName string) (*Package, error) {
 		return nil, ErrFailedToParsePackage
 	}
 
-	pkgAllVersions, err := g.getPackageAllVersion(packageName)
+	pkgAllVersions, err := g.getPackageAllVersion(ctx, packageName)
 	if err != nil {
 		return nil, err
 	}
@@ -75,11 +108,15 @@ func (g goPackageDiscovery) GetPackage(packageName string) (*Package, error) {
 }
 
 func (g goPackageDiscovery) GetPackageDependencies(packageName string, packageVersion string) (*PackageDependencyList, error) {
+	return g.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+func (g goPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName string, packageVersion string) (*PackageDependencyList, error) {
 	url := goProxyAPIEndpointGetPackageModFileFromVersion(packageName, packageVersion)
-	res, err := httpClient().Get(url)
+	res, err := g.proxies.get(ctx, url)
 
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -121,12 +158,16 @@ func (g goPackageDiscovery) GetPackageDownloadStats(packageName string) (Downloa
 	return DownloadStats{}, ErrOperationNotSupported
 }
 
-func (g goPackageDiscovery) getPackageAllVersion(packageName string) ([]PackageVersionInfo, error) {
+func (g goPackageDiscovery) GetPackageDownloadStatsContext(_ context.Context, packageName string) (DownloadStats, error) {
+	return DownloadStats{}, ErrOperationNotSupported
+}
+
+func (g goPackageDiscovery) getPackageAllVersion(ctx context.Context, packageName string) ([]PackageVersionInfo, error) {
 	url := goProxyAPIEndpointPackageListAllVersions(packageName)
 
-	res, err := httpClient().Get(url)
+	res, err := g.proxies.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
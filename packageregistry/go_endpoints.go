@@ -1,18 +1,130 @@
 package packageregistry
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // Public Go Module Proxy: proxy.golang.org
 // Protocol (Endpoint) Docs: https://go.dev/ref/mod#module-proxy
 
+const goProxyDefaultURL = "https://proxy.golang.org"
+
 func goProxyAPIEndpointPackageLatestVersionURL(packageName string) string {
-	return fmt.Sprintf("https://proxy.golang.org/%s/@latest", packageName)
+	return fmt.Sprintf("/%s/@latest", packageName)
 }
 
 func goProxyAPIEndpointPackageListAllVersions(packageName string) string {
-	return fmt.Sprintf("https://proxy.golang.org/%s/@v/list", packageName)
+	return fmt.Sprintf("/%s/@v/list", packageName)
 }
 
 func goProxyAPIEndpointGetPackageModFileFromVersion(packageName, packageVersion string) string {
-	return fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.mod", packageName, packageVersion)
+	return fmt.Sprintf("/%s/@v/%s.mod", packageName, packageVersion)
+}
+
+// goProxy is a module proxy of a GOPROXY list
+type goProxy struct {
+	endpoint registryEndpoint
+
+	// Try the next proxy after any error instead of only after 404 and 410
+	fallbackOnError bool
+}
+
+// goProxyList is a list of module proxies tried in order, following the
+// semantics of GOPROXY
+type goProxyList struct {
+	proxies []goProxy
+
+	// Lookups are disabled by "off"
+	disabled bool
+}
+
+// newGoProxyList parses a GOPROXY style list. Fetching modules from version
+// control is not supported, so "direct" ends the list
+func newGoProxyList(config *RegistryAdapterConfig) (goProxyList, error) {
+	value := config.GoProxy.BaseURL
+	if value == "" {
+		value = goProxyDefaultURL
+	}
+
+	var list goProxyList
+	for value != "" {
+		entry := value
+		fallbackOnError := false
+
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			entry = value[:i]
+			fallbackOnError = value[i] == '|'
+			value = value[i+1:]
+		} else {
+			value = ""
+		}
+
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case "direct":
+			value = ""
+			continue
+		case "off":
+			list.disabled = true
+			value = ""
+			continue
+		}
+
+		// Auth is meant for the first proxy, usually a private one, and is
+		// not sent to fallbacks such as proxy.golang.org on other hosts
+		var auth RegistryAuth
+		if len(list.proxies) == 0 || sameOrigin(list.proxies[0].endpoint.baseURL, entry) {
+			auth = config.GoProxy.Auth
+		}
+
+		endpoint, err := newRegistryEndpoint(config, RegistryEndpoint{BaseURL: entry, Auth: auth}, "")
+		if err != nil {
+			return goProxyList{}, err
+		}
+
+		list.proxies = append(list.proxies, goProxy{endpoint: endpoint, fallbackOnError: fallbackOnError})
+	}
+
+	if len(list.proxies) == 0 && !list.disabled {
+		return goProxyList{}, fmt.Errorf("go proxy list %q has no proxies", config.GoProxy.BaseURL)
+	}
+
+	return list, nil
+}
+
+// get requests path from the proxies in order. The response of the last
+// proxy tried is returned so that callers handle 404 as for a single proxy
+func (l goProxyList) get(ctx context.Context, path string) (*http.Response, error) {
+	if len(l.proxies) == 0 {
+		return nil, fmt.Errorf("%w: module lookup disabled by GOPROXY=off", ErrFailedToFetchPackage)
+	}
+
+	for i, proxy := range l.proxies {
+		last := i == len(l.proxies)-1
+
+		res, err := proxy.endpoint.get(ctx, proxy.endpoint.baseURL+path)
+		if err != nil {
+			if last || !proxy.fallbackOnError || ctx.Err() != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		fallback := res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone ||
+			(proxy.fallbackOnError && res.StatusCode != http.StatusOK)
+		if last || !fallback {
+			return res, nil
+		}
+
+		_ = res.Body.Close()
+	}
+
+	// Not reachable since the last proxy always returns
+	return nil, ErrFailedToFetchPackage
 }
@@ -1,6 +1,7 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -11,13 +12,32 @@ import (
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 )
 
-type mavenAdapter struct{}
-type mavenPublisherDiscovery struct{}
-type mavenPackageDiscovery struct{}
+type mavenAdapter struct {
+	registry mavenRegistry
+}
+
+type mavenPublisherDiscovery struct {
+	registry mavenRegistry
+}
+
+type mavenPackageDiscovery struct {
+	registry mavenRegistry
+}
+
+// mavenRegistry holds the endpoints of the search API and the repository
+type mavenRegistry struct {
+	search     registryEndpoint
+	repository registryEndpoint
+}
 
 // Verify that mavenAdapter implements the Client interface
 var _ Client = (*mavenAdapter)(nil)
 
+var (
+	_ ContextPublisherDiscovery = (*mavenPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*mavenPackageDiscovery)(nil)
+)
+
 // parseMavenCoordinates parses a Maven package name in the format "groupId:artifactId"
 // and returns the groupId and artifactId components, or an error if the format is invalid
 func parseMavenCoordinates(packageName string) (groupId, artifactId string, err error) {
@@ -30,19 +50,37 @@ func parseMavenCoordinates(packageName string) (groupId, artifactId string, err
 
 // NewMavenAdapter creates a new Maven registry adapter
 func NewMavenAdapter() (Client, error) {
-	return &mavenAdapter{}, nil
+	return newMavenAdapter(&RegistryAdapterConfig{})
+}
+
+func newMavenAdapter(config *RegistryAdapterConfig) (Client, error) {
+	search, err := newRegistryEndpoint(config, config.MavenSearch, mavenDefaultSearchURL)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := newRegistryEndpoint(config, config.Maven, mavenDefaultRepositoryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mavenAdapter{registry: mavenRegistry{search: search, repository: repository}}, nil
 }
 
 func (ma *mavenAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
-	return &mavenPublisherDiscovery{}, nil
+	return &mavenPublisherDiscovery{registry: ma.registry}, nil
 }
 
 func (ma *mavenAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &mavenPackageDiscovery{}, nil
+	return &mavenPackageDiscovery{registry: ma.registry}, nil
 }
 
 // GetPackagePublisher returns the publisher of a Maven package
 func (mp *mavenPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return mp.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (mp *mavenPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	packageName := packageVersion.GetPackage().GetName()
 
 	// For Maven, we need to parse the package name to get groupId and artifactId
@@ -52,7 +90,7 @@ func (mp *mavenPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1
 	}
 
 	// Get package details
-	searchResult, err := mavenGetPackageSearchResult(groupId, artifactId)
+	searchResult, err := mavenGetPackageSearchResult(ctx, mp.registry, groupId, artifactId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get package details: %w", err)
 	}
@@ -78,12 +116,16 @@ func (mp *mavenPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1
 // GetPublisherPackages returns all packages published by a given publisher
 // This is limited to max limit. See mavenSearchRows constant in maven_endpoints.go
 func (mp *mavenPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
+	return mp.GetPublisherPackagesContext(context.Background(), publisher)
+}
+
+func (mp *mavenPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
 	// Search for packages by groupId (using publisher name as groupId)
-	url := mavenAPIEndpointPackagesByGroupURL(publisher.Name)
+	url := mavenAPIEndpointPackagesByGroupURL(mp.registry.search.baseURL, publisher.Name)
 
-	res, err := httpClient().Get(url)
+	res, err := mp.registry.search.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -109,8 +151,12 @@ func (mp *mavenPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]
 	for _, doc := range searchResult.Response.Docs {
 		// N+1 query here to get the package details. There is no way to avoid this.
 		// We need to fetch the package details to get the publisher information.
-		pkg, err := convertMavenDocToPackage(doc)
+		pkg, err := convertMavenDocToPackage(ctx, mp.registry, doc)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+
 			continue // Skip packages with conversion errors
 		}
 		packages = append(packages, pkg)
@@ -120,16 +166,24 @@ func (mp *mavenPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]
 }
 
 func (mp *mavenPackageDiscovery) GetPackage(packageName string) (*Package, error) {
+	return mp.GetPackageContext(context.Background(), packageName)
+}
+
+func (mp *mavenPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
 	// Parse Maven package name (groupId:artifactId)
 	groupId, artifactId, err := parseMavenCoordinates(packageName)
 	if err != nil {
 		return nil, err
 	}
 
-	return mavenGetPackageDetails(groupId, artifactId)
+	return mavenGetPackageDetails(ctx, mp.registry, groupId, artifactId)
 }
 
 func (mp *mavenPackageDiscovery) GetPackageDependencies(packageName string, packageVersion string) (*PackageDependencyList, error) {
+	return mp.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+func (mp *mavenPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName string, packageVersion string) (*PackageDependencyList, error) {
 	// Parse Maven package name (groupId:artifactId)
 	groupId, artifactId, err := parseMavenCoordinates(packageName)
 	if err != nil {
@@ -137,7 +191,7 @@ func (mp *mavenPackageDiscovery) GetPackageDependencies(packageName string, pack
 	}
 
 	// Fetch and parse the pom.xml file
-	pom, err := mavenFetchAndParsePOM(groupId, artifactId, packageVersion)
+	pom, err := mavenFetchAndParsePOM(ctx, mp.registry, groupId, artifactId, packageVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch or parse POM: %w", err)
 	}
@@ -178,16 +232,20 @@ func (mp *mavenPackageDiscovery) GetPackageDependencies(packageName string, pack
 }
 
 func (mp *mavenPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return mp.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (mp *mavenPackageDiscovery) GetPackageDownloadStatsContext(_ context.Context, packageName string) (DownloadStats, error) {
 	// Maven Central doesn't provide download statistics through their public API
 	return DownloadStats{}, fmt.Errorf("download stats are not available for Maven Central")
 }
 
-func mavenGetPackageSearchResult(groupId, artifactId string) (*mavenSearchResponse, error) {
-	url := mavenAPIEndpointPackageURL(groupId, artifactId)
+func mavenGetPackageSearchResult(ctx context.Context, registry mavenRegistry, groupId, artifactId string) (*mavenSearchResponse, error) {
+	url := mavenAPIEndpointPackageURL(registry.search.baseURL, groupId, artifactId)
 
-	res, err := httpClient().Get(url)
+	res, err := registry.search.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -208,8 +266,8 @@ func mavenGetPackageSearchResult(groupId, artifactId string) (*mavenSearchRespon
 	return &searchResult, nil
 }
 
-func mavenGetPackageDetails(groupId, artifactId string) (*Package, error) {
-	searchResult, err := mavenGetPackageSearchResult(groupId, artifactId)
+func mavenGetPackageDetails(ctx context.Context, registry mavenRegistry, groupId, artifactId string) (*Package, error) {
+	searchResult, err := mavenGetPackageSearchResult(ctx, registry, groupId, artifactId)
 	if err != nil {
 		return nil, err
 	}
@@ -220,16 +278,21 @@ func mavenGetPackageDetails(groupId, artifactId string) (*Package, error) {
 
 	// Get the most recent version (first in the list)
 	doc := searchResult.Response.Docs[0]
-	return convertMavenDocToPackage(doc)
+	return convertMavenDocToPackage(ctx, registry, doc)
 }
 
-func convertMavenDocToPackage(doc mavenDoc) (*Package, error) {
+func convertMavenDocToPackage(ctx context.Context, registry mavenRegistry, doc mavenDoc) (*Package, error) {
 	// Get all versions for this package using GAV core search
 	versions := make([]PackageVersionInfo, 0)
 
 	// Fetch all versions using the GAV core
-	gavVersions, err := mavenGetAllVersions(doc.GroupId, doc.ArtifactId)
+	gavVersions, err := mavenGetAllVersions(ctx, registry, doc.GroupId, doc.ArtifactId)
 	if err != nil {
+		// A cancelled request is not a missing version list
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
 		// If we can't get all versions, at least include the latest
 		if doc.LatestVersion != "" {
 			versions = append(versions, PackageVersionInfo{
@@ -269,10 +332,10 @@ func convertMavenDocToPackage(doc mavenDoc) (*Package, error) {
 	return &pkg, nil
 }
 
-func mavenGetAllVersions(groupId, artifactId string) ([]PackageVersionInfo, error) {
-	url := mavenAPIEndpointPackageVersionsURL(groupId, artifactId)
+func mavenGetAllVersions(ctx context.Context, registry mavenRegistry, groupId, artifactId string) ([]PackageVersionInfo, error) {
+	url := mavenAPIEndpointPackageVersionsURL(registry.search.baseURL, groupId, artifactId)
 
-	res, err := httpClient().Get(url)
+	res, err := registry.search.get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -306,10 +369,10 @@ func mavenParseTimestamp(timestamp int64) time.Time {
 }
 
 // mavenFetchAndParsePOM fetches the pom.xml file for a given package version and parses it
-func mavenFetchAndParsePOM(groupId, artifactId, version string) (*mavenPOM, error) {
-	url := mavenAPIEndpointPomURL(groupId, artifactId, version)
+func mavenFetchAndParsePOM(ctx context.Context, registry mavenRegistry, groupId, artifactId, version string) (*mavenPOM, error) {
+	url := mavenAPIEndpointPomURL(registry.repository.baseURL, groupId, artifactId, version)
 
-	res, err := httpClient().Get(url)
+	res, err := registry.repository.get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch POM file: %w", err)
 	}
@@ -8,32 +8,35 @@ import (
 
 const (
 	mavenSearchRows = 100
+
+	mavenDefaultSearchURL     = "https://search.maven.org/solrsearch"
+	mavenDefaultRepositoryURL = "https://repo1.maven.org/maven2"
 )
 
 // Maven Central Search API Endpoints
 // Docs: https://central.sonatype.org/search/rest-api-guide/
 
-func mavenAPIEndpointPackageURL(groupId, artifactId string) string {
+func mavenAPIEndpointPackageURL(baseURL, groupId, artifactId string) string {
 	// Search for specific groupId and artifactId
 	query := fmt.Sprintf("g:%s AND a:%s", groupId, artifactId)
-	return fmt.Sprintf("https://search.maven.org/solrsearch/select?q=%s&rows=%d&wt=json", url.QueryEscape(query), mavenSearchRows)
+	return fmt.Sprintf("%s/select?q=%s&rows=%d&wt=json", baseURL, url.QueryEscape(query), mavenSearchRows)
 }
 
-func mavenAPIEndpointPackagesByGroupURL(groupId string) string {
+func mavenAPIEndpointPackagesByGroupURL(baseURL, groupId string) string {
 	// Search for all packages in a specific groupId
 	query := fmt.Sprintf("g:%s", groupId)
-	return fmt.Sprintf("https://search.maven.org/solrsearch/select?q=%s&rows=%d&wt=json", url.QueryEscape(query), mavenSearchRows)
+	return fmt.Sprintf("%s/select?q=%s&rows=%d&wt=json", baseURL, url.QueryEscape(query), mavenSearchRows)
 }
 
-func mavenAPIEndpointPackageVersionsURL(groupId, artifactId string) string {
+func mavenAPIEndpointPackageVersionsURL(baseURL, groupId, artifactId string) string {
 	// Search for all versions of a specific artifact
 	query := fmt.Sprintf("g:%s AND a:%s", groupId, artifactId)
-	return fmt.Sprintf("https://search.maven.org/solrsearch/select?q=%s&core=gav&rows=%d&wt=json", url.QueryEscape(query), mavenSearchRows)
+	return fmt.Sprintf("%s/select?q=%s&core=gav&rows=%d&wt=json", baseURL, url.QueryEscape(query), mavenSearchRows)
 }
 
 // mavenAPIEndpointPomURL constructs the URL to fetch the pom.xml file for a specific package version
-func mavenAPIEndpointPomURL(groupId, artifactId, version string) string {
+func mavenAPIEndpointPomURL(baseURL, groupId, artifactId, version string) string {
 	// Convert groupId to path format (e.g., "org.apache.commons" -> "org/apache/commons")
 	groupPath := strings.ReplaceAll(groupId, ".", "/")
-	return fmt.Sprintf("https://repo1.maven.org/maven2/%s/%s/%s/%s-%s.pom", groupPath, artifactId, version, artifactId, version)
+	return fmt.Sprintf("%s/%s/%s/%s/%s-%s.pom", baseURL, groupPath, artifactId, version, artifactId, version)
 }
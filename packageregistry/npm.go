@@ -1,6 +1,7 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,32 +11,77 @@ import (
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 )
 
-type npmAdapter struct{}
-type npmPublisherDiscovery struct{}
-type npmPackageDiscovery struct{}
+type npmAdapter struct {
+	registry npmRegistry
+}
+
+type npmPublisherDiscovery struct {
+	registry npmRegistry
+}
+
+type npmPackageDiscovery struct {
+	registry npmRegistry
+}
+
+// npmRegistry holds the endpoints of the npm registry and the downloads API
+type npmRegistry struct {
+	packages registryEndpoint
+
+	// Nil for a private registry without a downloads API, so that its
+	// package names are not sent to the public API
+	downloads *registryEndpoint
+}
 
 // Verify that npmAdapter implements the Client interface
 var _ Client = (*npmAdapter)(nil)
 
+var (
+	_ ContextPublisherDiscovery = (*npmPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*npmPackageDiscovery)(nil)
+)
+
 // NewNpmAdapter creates a new NPM registry adapter
 func NewNpmAdapter() (Client, error) {
-	return &npmAdapter{}, nil
+	return newNpmAdapter(&RegistryAdapterConfig{})
+}
+
+func newNpmAdapter(config *RegistryAdapterConfig) (Client, error) {
+	packages, err := newRegistryEndpoint(config, config.Npm, npmDefaultRegistryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := npmRegistry{packages: packages}
+	if config.Npm.BaseURL == "" || config.NpmDownloads.BaseURL != "" {
+		downloads, err := newRegistryEndpoint(config, config.NpmDownloads, npmDefaultDownloadsURL)
+		if err != nil {
+			return nil, err
+		}
+
+		registry.downloads = &downloads
+	}
+
+	return &npmAdapter{registry: registry}, nil
 }
 
 func (na *npmAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
-	return &npmPublisherDiscovery{}, nil
+	return &npmPublisherDiscovery{registry: na.registry}, nil
 }
 
 func (na *npmAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &npmPackageDiscovery{}, nil
+	return &npmPackageDiscovery{registry: na.registry}, nil
 }
 
 // GetPackagePublisher returns the publisher of a package
 func (np *npmPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return np.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (np *npmPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	packageName := packageVersion.GetPackage().GetName()
 	version := packageVersion.GetVersion()
 
-	npmpkg, err := npmGetPackageVersionDetails(packageName, version)
+	npmpkg, err := npmGetPackageVersionDetails(ctx, np.registry, packageName, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get package version details: %w", err)
 	}
@@ -54,11 +100,15 @@ func (np *npmPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.P
 
 // GetPublisherPackages returns all the packages published by a given publisher
 func (np *npmPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
-	url := npmAPIEndpointPackageSearchWithAuthorURL(publisher.Name)
+	return np.GetPublisherPackagesContext(context.Background(), publisher)
+}
 
-	res, err := httpClient().Get(url)
+func (np *npmPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
+	url := npmAPIEndpointPackageSearchWithAuthorURL(np.registry.packages.baseURL, publisher.Name)
+
+	res, err := np.registry.packages.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -82,7 +132,7 @@ func (np *npmPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*P
 
 	var packages []*Package
 	for _, obj := range pubRecord.Objects {
-		pkg, err := npmGetPackageDetails(obj.Package.Name)
+		pkg, err := npmGetPackageDetails(ctx, np.registry, obj.Package.Name)
 		if err != nil {
 			if errors.Is(err, ErrPackageNotFound) {
 				continue
@@ -100,11 +150,19 @@ func (np *npmPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*P
 }
 
 func (np *npmPackageDiscovery) GetPackage(packageName string) (*Package, error) {
-	return npmGetPackageDetails(packageName)
+	return np.GetPackageContext(context.Background(), packageName)
+}
+
+func (np *npmPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
+	return npmGetPackageDetails(ctx, np.registry, packageName)
 }
 
 func (np *npmPackageDiscovery) GetPackageDependencies(packageName string, packageVersion string) (*PackageDependencyList, error) {
-	npmpkg, err := npmGetPackageVersionDetails(packageName, packageVersion)
+	return np.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+func (np *npmPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName string, packageVersion string) (*PackageDependencyList, error) {
+	npmpkg, err := npmGetPackageVersionDetails(ctx, np.registry, packageName, packageVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +190,10 @@ func (np *npmPackageDiscovery) GetPackageDependencies(packageName string, packag
 }
 
 func (np *npmPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return np.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (np *npmPackageDiscovery) GetPackageDownloadStatsContext(ctx context.Context, packageName string) (DownloadStats, error) {
 	downloadPeriods := []npmDownloadPeriod{
 		npmDownloadsPeriodLastDay,
 		npmDownloadsPeriodLastWeek,
@@ -142,7 +204,7 @@ func (np *npmPackageDiscovery) GetPackageDownloadStats(packageName string) (Down
 
 	var err error
 	for _, period := range downloadPeriods {
-		periodWiseDownloads[period], err = npmGetPackageDownloadsForPeriod(packageName, period)
+		periodWiseDownloads[period], err = npmGetPackageDownloadsForPeriod(ctx, np.registry, packageName, period)
 		if err != nil {
 			return DownloadStats{}, err
 		}
@@ -156,12 +218,12 @@ func (np *npmPackageDiscovery) GetPackageDownloadStats(packageName string) (Down
 	}, nil
 }
 
-func npmGetPackageVersionDetails(packageName string, packageVersion string) (*npmPackageVersionInfo, error) {
-	url := npmAPIEndpointPackageWithVersionURL(packageName, packageVersion)
+func npmGetPackageVersionDetails(ctx context.Context, registry npmRegistry, packageName string, packageVersion string) (*npmPackageVersionInfo, error) {
+	url := npmAPIEndpointPackageWithVersionURL(registry.packages.baseURL, packageName, packageVersion)
 
-	res, err := httpClient().Get(url)
+	res, err := registry.packages.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -182,12 +244,12 @@ func npmGetPackageVersionDetails(packageName string, packageVersion string) (*np
 	return &npmpkg, nil
 }
 
-func npmGetPackageDetails(packageName string) (*Package, error) {
-	url := npmAPIEndpointPackageURL(packageName)
+func npmGetPackageDetails(ctx context.Context, registry npmRegistry, packageName string) (*Package, error) {
+	url := npmAPIEndpointPackageURL(registry.packages.baseURL, packageName)
 
-	res, err := httpClient().Get(url)
+	res, err := registry.packages.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 	defer res.Body.Close()
 
@@ -234,9 +296,15 @@ func npmGetPackageDetails(packageName string) (*Package, error) {
 		})
 	}
 
-	downloads, err := npmGetPackageDownloadsForPeriod(packageName, npmDownloadsPeriodLastYear)
+	// Downloads are optional since mirrors usually do not serve them and
+	// the public API does not know private packages
+	downloads, err := npmGetPackageDownloadsForPeriod(ctx, registry, packageName, npmDownloadsPeriodLastYear)
 	if err != nil {
-		return nil, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToFetchPackage, ctxErr)
+		}
+
+		downloads = 0
 	}
 
 	sourceGitURL, err := getNormalizedGitURL(npmpkg.Repository.Url)
@@ -273,12 +341,16 @@ const (
 	npmDownloadsPeriodLastYear  npmDownloadPeriod = "last-year"
 )
 
-func npmGetPackageDownloadsForPeriod(packageName string, period npmDownloadPeriod) (uint64, error) {
-	url := npmAPIEndpointPackageDownloadsURL(packageName, period)
+func npmGetPackageDownloadsForPeriod(ctx context.Context, registry npmRegistry, packageName string, period npmDownloadPeriod) (uint64, error) {
+	if registry.downloads == nil {
+		return 0, fmt.Errorf("%w: npm downloads API is not configured for the registry", ErrOperationNotSupported)
+	}
 
-	res, err := httpClient().Get(url)
+	url := npmAPIEndpointPackageDownloadsURL(registry.downloads.baseURL, packageName, period)
+
+	res, err := registry.downloads.get(ctx, url)
 	if err != nil {
-		return 0, ErrFailedToFetchPackage
+		return 0, err
 	}
 	defer res.Body.Close()
 
@@ -5,20 +5,25 @@ import "fmt"
 // Npm API Endpoints
 // Docs: https://github.com/npm/registry/blob/main/docs/REGISTRY-API.md
 
-func npmAPIEndpointPackageURL(packageName string) string {
-	return fmt.Sprintf("https://registry.npmjs.org/%s", packageName)
+const (
+	npmDefaultRegistryURL  = "https://registry.npmjs.org"
+	npmDefaultDownloadsURL = "https://api.npmjs.org"
+)
+
+func npmAPIEndpointPackageURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/%s", baseURL, packageName)
 }
 
-func npmAPIEndpointPackageWithVersionURL(packageName, version string) string {
-	return fmt.Sprintf("https://registry.npmjs.org/%s/%s", packageName, version)
+func npmAPIEndpointPackageWithVersionURL(baseURL, packageName, version string) string {
+	return fmt.Sprintf("%s/%s/%s", baseURL, packageName, version)
 }
 
-func npmAPIEndpointPackageSearchWithAuthorURL(author string) string {
-	return fmt.Sprintf("https://registry.npmjs.org/-/v1/search?text=author:%s", author)
+func npmAPIEndpointPackageSearchWithAuthorURL(baseURL, author string) string {
+	return fmt.Sprintf("%s/-/v1/search?text=author:%s", baseURL, author)
 }
 
 // Gets the download count for a package in the specified period
 // periodPoint can be "last-day", "last-week", "last-month", "last-year"
-func npmAPIEndpointPackageDownloadsURL(packageName string, periodPoint npmDownloadPeriod) string {
-	return fmt.Sprintf("https://api.npmjs.org/downloads/point/%s/%s", periodPoint, packageName)
+func npmAPIEndpointPackageDownloadsURL(baseURL, packageName string, periodPoint npmDownloadPeriod) string {
+	return fmt.Sprintf("%s/downloads/point/%s/%s", baseURL, periodPoint, packageName)
 }
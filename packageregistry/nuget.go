@@ -0,0 +1,525 @@
+package packageregistry
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+)
+
+// Will traverse maximum of 5 search pages for the packages of a publisher
+const nugetMaxPublisherPackagesPages = 5
+
+type nugetAdapter struct {
+	feed *nugetFeed
+}
+
+type nugetPublisherDiscovery struct {
+	feed *nugetFeed
+}
+
+type nugetPackageDiscovery struct {
+	feed *nugetFeed
+}
+
+// nugetFeed is a NuGet V3 feed whose resources are resolved from the
+// service index on first use and shared by the discovery clients
+type nugetFeed struct {
+	index registryEndpoint
+
+	mu        sync.Mutex
+	resources *nugetResources
+}
+
+// nugetResources holds the base URLs of the feed resources we use
+type nugetResources struct {
+	registrations      string
+	packageBaseAddress string
+
+	// Empty when the feed has no search service
+	search string
+}
+
+// Verify that nugetAdapter implements the Client interface
+var _ Client = (*nugetAdapter)(nil)
+
+var (
+	_ ContextPublisherDiscovery = (*nugetPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*nugetPackageDiscovery)(nil)
+)
+
+// NewNugetAdapter creates a new NuGet registry adapter
+func NewNugetAdapter() (Client, error) {
+	return newNugetAdapter(&RegistryAdapterConfig{})
+}
+
+func newNugetAdapter(config *RegistryAdapterConfig) (Client, error) {
+	index, err := newRegistryEndpoint(config, config.NuGet, nugetDefaultServiceIndexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nugetAdapter{feed: &nugetFeed{index: index}}, nil
+}
+
+func (na *nugetAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
+	return &nugetPublisherDiscovery{feed: na.feed}, nil
+}
+
+func (na *nugetAdapter) PackageDiscovery() (PackageDiscovery, error) {
+	return &nugetPackageDiscovery{feed: na.feed}, nil
+}
+
+// GetPackagePublisher returns the owners of a package. NuGet packages have
+// the same owners for all versions
+func (np *nugetPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return np.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (np *nugetPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	result, err := nugetSearchPackage(ctx, np.feed, packageVersion.GetPackage().GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Owners) == 0 {
+		return nil, ErrAuthorNotFound
+	}
+
+	// Verified packages have an ID prefix reserved by their owners
+	publishers := nugetOwnersToPublishers(result.Owners)
+	for i := range publishers {
+		publishers[i].VerificationStatus = &PublisherVerificationStatus{IsVerified: result.Verified}
+	}
+
+	return &PackagePublisherInfo{Publishers: publishers}, nil
+}
+
+// GetPublisherPackages returns the packages owned by a publisher. The
+// packages are built from search results, so their versions do not have
+// publish dates
+func (np *nugetPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
+	return np.GetPublisherPackagesContext(context.Background(), publisher)
+}
+
+func (np *nugetPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
+	if publisher.Name == "" {
+		return nil, ErrAuthorNotFound
+	}
+
+	resources, err := np.feed.getResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if resources.search == "" {
+		return nil, fmt.Errorf("%w: nuget feed has no search service", ErrOperationNotSupported)
+	}
+
+	packages := make([]*Package, 0)
+	for page := 0; page < nugetMaxPublisherPackagesPages; page++ {
+		searchURL := nugetAPIEndpointSearchURL(resources.search, "owner:"+publisher.Name, page*nugetSearchPageSize)
+
+		var searchResults nugetSearchResponse
+		if err := np.feed.getJSON(ctx, searchURL, &searchResults); err != nil {
+			return nil, err
+		}
+
+		// The owner filter of the search service is not an exact match
+		for _, result := range searchResults.Data {
+			for _, owner := range result.Owners {
+				if strings.EqualFold(owner, publisher.Name) {
+					packages = append(packages, nugetSearchResultToPackage(result))
+					break
+				}
+			}
+		}
+
+		if len(searchResults.Data) < nugetSearchPageSize {
+			break
+		}
+	}
+
+	if len(packages) == 0 {
+		return nil, ErrNoPackagesFound
+	}
+
+	return packages, nil
+}
+
+func (np *nugetPackageDiscovery) GetPackage(packageName string) (*Package, error) {
+	return np.GetPackageContext(context.Background(), packageName)
+}
+
+func (np *nugetPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
+	return nugetGetPackageDetails(ctx, np.feed, packageName)
+}
+
+func (np *nugetPackageDiscovery) GetPackageDependencies(packageName, packageVersion string) (*PackageDependencyList, error) {
+	return np.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+// GetPackageDependenciesContext returns the dependencies of a package version
+// from its nuspec. Dependencies declared for a target framework have it set
+// in TargetFramework, so a dependency can be listed once per framework.
+// NuGet has no development dependencies
+func (np *nugetPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName, packageVersion string) (*PackageDependencyList, error) {
+	nuspec, err := nugetGetNuspec(ctx, np.feed, packageName, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := make([]PackageDependencyInfo, 0)
+	for _, dependency := range nuspec.Metadata.Dependencies.Dependencies {
+		dependencies = append(dependencies, PackageDependencyInfo{
+			Name:        dependency.ID,
+			VersionSpec: dependency.Version,
+		})
+	}
+
+	for _, group := range nuspec.Metadata.Dependencies.Groups {
+		for _, dependency := range group.Dependencies {
+			dependencies = append(dependencies, PackageDependencyInfo{
+				Name:            dependency.ID,
+				VersionSpec:     dependency.Version,
+				TargetFramework: group.TargetFramework,
+			})
+		}
+	}
+
+	return &PackageDependencyList{
+		Dependencies:    dependencies,
+		DevDependencies: make([]PackageDependencyInfo, 0),
+	}, nil
+}
+
+func (np *nugetPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return np.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+// GetPackageDownloadStatsContext returns the total downloads of a package.
+// NuGet does not provide daily, weekly or monthly downloads
+func (np *nugetPackageDiscovery) GetPackageDownloadStatsContext(ctx context.Context, packageName string) (DownloadStats, error) {
+	result, err := nugetSearchPackage(ctx, np.feed, packageName)
+	if err != nil {
+		return DownloadStats{}, err
+	}
+
+	return DownloadStats{Total: result.TotalDownloads}, nil
+}
+
+// nugetGetPackageDetails builds a package from its registration, the
+// repository in the nuspec of the latest version and, when the feed has a
+// search service, its owners and downloads
+func nugetGetPackageDetails(ctx context.Context, feed *nugetFeed, packageName string) (*Package, error) {
+	entries, err := nugetGetRegistrationEntries(ctx, feed, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrPackageNotFound
+	}
+
+	// Registration entries are sorted by version. The latest version is the
+	// highest listed stable version, or prerelease when there is none
+	var latest, latestStable *nugetCatalogEntry
+	var createdAt, updatedAt time.Time
+
+	versions := make([]PackageVersionInfo, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Listed != nil && !*entry.Listed {
+			continue
+		}
+
+		versions = append(versions, PackageVersionInfo{
+			Version:     entry.Version,
+			PublishedAt: &entry.Published,
+		})
+
+		if createdAt.IsZero() || entry.Published.Before(createdAt) {
+			createdAt = entry.Published
+		}
+
+		if entry.Published.After(updatedAt) {
+			updatedAt = entry.Published
+		}
+
+		latest = entry
+		if !nugetIsPrerelease(entry.Version) {
+			latestStable = entry
+		}
+	}
+
+	if latestStable != nil {
+		latest = latestStable
+	}
+
+	pkg := &Package{
+		Name:      entries[len(entries)-1].ID,
+		Versions:  versions,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+
+	if latest != nil {
+		pkg.Name = latest.ID
+		pkg.Description = latest.Description
+		pkg.LatestVersion = latest.Version
+		pkg.Author = Publisher{Name: strings.Join(latest.Authors, ", ")}
+
+		nuspec, err := nugetGetNuspec(ctx, feed, packageName, latest.Version)
+		if err != nil && !errors.Is(err, ErrPackageNotFound) {
+			return nil, err
+		}
+
+		if nuspec != nil {
+			pkg.SourceRepositoryUrl, err = getNormalizedGitURL(nuspec.Metadata.Repository.URL)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The search index lags behind registrations, so new packages can be
+	// missing from it
+	result, err := nugetSearchPackage(ctx, feed, packageName)
+	switch {
+	case err == nil:
+		pkg.Maintainers = nugetOwnersToPublishers(result.Owners)
+		pkg.Downloads = OptionalInt{Value: result.TotalDownloads, Valid: result.TotalDownloads > 0}
+	case errors.Is(err, ErrPackageNotFound), errors.Is(err, ErrOperationNotSupported):
+	default:
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+// nugetGetRegistrationEntries returns the catalog entries of all versions of
+// a package, fetching the pages that are not inlined in the index
+func nugetGetRegistrationEntries(ctx context.Context, feed *nugetFeed, packageName string) ([]nugetCatalogEntry, error) {
+	resources, err := feed.getResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var index nugetRegistrationIndex
+	err = feed.getJSON(ctx, nugetAPIEndpointRegistrationIndexURL(resources.registrations, packageName), &index)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]nugetCatalogEntry, 0)
+	for _, page := range index.Items {
+		if len(page.Items) == 0 && page.Count > 0 {
+			if err := feed.getJSON(ctx, page.ID, &page); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, leaf := range page.Items {
+			entries = append(entries, leaf.CatalogEntry)
+		}
+	}
+
+	return entries, nil
+}
+
+func nugetGetNuspec(ctx context.Context, feed *nugetFeed, packageName, version string) (*nugetNuspec, error) {
+	resources, err := feed.getResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := feed.fetch(ctx, nugetAPIEndpointNuspecURL(resources.packageBaseAddress, packageName, version))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var nuspec nugetNuspec
+	err = xml.NewDecoder(body).Decode(&nuspec)
+	if err != nil {
+		return nil, ErrFailedToParsePackage
+	}
+
+	return &nuspec, nil
+}
+
+// nugetSearchPackage returns the search result of a package. Search is
+// case insensitive, like package IDs
+func nugetSearchPackage(ctx context.Context, feed *nugetFeed, packageName string) (*nugetSearchResult, error) {
+	resources, err := feed.getResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if resources.search == "" {
+		return nil, fmt.Errorf("%w: nuget feed has no search service", ErrOperationNotSupported)
+	}
+
+	var searchResults nugetSearchResponse
+	err = feed.getJSON(ctx, nugetAPIEndpointSearchURL(resources.search, "packageid:"+packageName, 0), &searchResults)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range searchResults.Data {
+		if strings.EqualFold(searchResults.Data[i].ID, packageName) {
+			return &searchResults.Data[i], nil
+		}
+	}
+
+	return nil, ErrPackageNotFound
+}
+
+func nugetSearchResultToPackage(result nugetSearchResult) *Package {
+	versions := make([]PackageVersionInfo, 0, len(result.Versions))
+	for _, version := range result.Versions {
+		versions = append(versions, PackageVersionInfo{Version: version.Version})
+	}
+
+	return &Package{
+		Name:          result.ID,
+		Description:   result.Description,
+		Author:        Publisher{Name: strings.Join(result.Authors, ", ")},
+		Maintainers:   nugetOwnersToPublishers(result.Owners),
+		LatestVersion: result.Version,
+		Versions:      versions,
+		Downloads:     OptionalInt{Value: result.TotalDownloads, Valid: result.TotalDownloads > 0},
+	}
+}
+
+func nugetOwnersToPublishers(owners nugetStrings) []Publisher {
+	publishers := make([]Publisher, 0, len(owners))
+	for _, owner := range owners {
+		publishers = append(publishers, Publisher{Name: owner})
+	}
+
+	return publishers
+}
+
+func nugetIsPrerelease(version string) bool {
+	version, _, _ = strings.Cut(version, "+")
+	return strings.Contains(version, "-")
+}
+
+// getResources fetches the service index until it is resolved. The lock is
+// not held during the request, so that callers are bound by their own ctx.
+// Failures are not cached so that a cancelled request does not break the feed
+func (f *nugetFeed) getResources(ctx context.Context) (*nugetResources, error) {
+	f.mu.Lock()
+	resources := f.resources
+	f.mu.Unlock()
+
+	if resources != nil {
+		return resources, nil
+	}
+
+	resources, err := f.fetchResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Keep the resources of a concurrent fetch that completed first
+	if f.resources == nil {
+		f.resources = resources
+	}
+
+	return f.resources, nil
+}
+
+func (f *nugetFeed) fetchResources(ctx context.Context) (*nugetResources, error) {
+	var index nugetServiceIndex
+	if err := f.getJSON(ctx, f.index.baseURL, &index); err != nil {
+		if errors.Is(err, ErrPackageNotFound) {
+			return nil, fmt.Errorf("%w: nuget service index not found", ErrFailedToFetchPackage)
+		}
+
+		return nil, err
+	}
+
+	resources := &nugetResources{
+		registrations:      nugetFindResource(index, nugetRegistrationsResourceTypes),
+		packageBaseAddress: nugetFindResource(index, nugetPackageBaseAddressResourceTypes),
+		search:             nugetFindResource(index, nugetSearchResourceTypes),
+	}
+
+	if resources.registrations == "" || resources.packageBaseAddress == "" {
+		return nil, fmt.Errorf("%w: nuget service index has no registrations or package base address", ErrFailedToFetchPackage)
+	}
+
+	return resources, nil
+}
+
+func nugetFindResource(index nugetServiceIndex, resourceTypes []string) string {
+	for _, resourceType := range resourceTypes {
+		for _, resource := range index.Resources {
+			if resource.Type == resourceType {
+				return strings.TrimSuffix(resource.ID, "/")
+			}
+		}
+	}
+
+	return ""
+}
+
+// get sends credentials only to resources on the host of the service
+// index, since feeds such as nuget.org serve search from another domain
+func (f *nugetFeed) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	endpoint := f.index
+	if !sameOrigin(endpoint.baseURL, requestURL) {
+		endpoint.auth = RegistryAuth{}
+	}
+
+	return endpoint.get(ctx, requestURL)
+}
+
+// fetch returns the body of a successful response, mapping 404 to
+// ErrPackageNotFound
+func (f *nugetFeed) fetch(ctx context.Context, requestURL string) (io.ReadCloser, error) {
+	res, err := f.get(ctx, requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrPackageNotFound
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, ErrFailedToFetchPackage
+	}
+
+	return res.Body, nil
+}
+
+func (f *nugetFeed) getJSON(ctx context.Context, requestURL string, v any) error {
+	body, err := f.fetch(ctx, requestURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	err = json.NewDecoder(body).Decode(v)
+	if err != nil {
+		return ErrFailedToParsePackage
+	}
+
+	return nil
+}
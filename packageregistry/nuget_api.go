@@ -0,0 +1,138 @@
+package packageregistry
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// nugetServiceIndex is the entry point of a NuGet V3 feed
+// API (sample): https://api.nuget.org/v3/index.json
+type nugetServiceIndex struct {
+	Version   string                 `json:"version"`
+	Resources []nugetServiceResource `json:"resources"`
+}
+
+type nugetServiceResource struct {
+	ID   string `json:"@id"`
+	Type string `json:"@type"`
+}
+
+// nugetRegistrationIndex lists the versions of a package in pages. Large
+// packages have pages without items that must be fetched separately
+// API (sample): https://api.nuget.org/v3/registration5-gz-semver2/newtonsoft.json/index.json
+type nugetRegistrationIndex struct {
+	Count int                     `json:"count"`
+	Items []nugetRegistrationPage `json:"items"`
+}
+
+type nugetRegistrationPage struct {
+	ID    string                  `json:"@id"`
+	Count int                     `json:"count"`
+	Lower string                  `json:"lower"`
+	Upper string                  `json:"upper"`
+	Items []nugetRegistrationLeaf `json:"items"`
+}
+
+type nugetRegistrationLeaf struct {
+	CatalogEntry nugetCatalogEntry `json:"catalogEntry"`
+}
+
+type nugetCatalogEntry struct {
+	ID          string       `json:"id"`
+	Version     string       `json:"version"`
+	Description string       `json:"description"`
+	Authors     nugetStrings `json:"authors"`
+	ProjectURL  string       `json:"projectUrl"`
+	Published   time.Time    `json:"published"`
+
+	// Unlisted versions can still be restored but are hidden from search.
+	// Feeds that do not support unlisting omit it
+	Listed *bool `json:"listed"`
+}
+
+// nugetSearchResponse is the response of the search service
+// API (sample): https://azuresearch-usnc.nuget.org/query?q=packageid:newtonsoft.json
+type nugetSearchResponse struct {
+	TotalHits int                 `json:"totalHits"`
+	Data      []nugetSearchResult `json:"data"`
+}
+
+type nugetSearchResult struct {
+	ID             string               `json:"id"`
+	Version        string               `json:"version"`
+	Description    string               `json:"description"`
+	Authors        nugetStrings         `json:"authors"`
+	Owners         nugetStrings         `json:"owners"`
+	ProjectURL     string               `json:"projectUrl"`
+	TotalDownloads uint64               `json:"totalDownloads"`
+	Verified       bool                 `json:"verified"`
+	Versions       []nugetSearchVersion `json:"versions"`
+}
+
+type nugetSearchVersion struct {
+	Version   string `json:"version"`
+	Downloads uint64 `json:"downloads"`
+}
+
+// nugetStrings is a list of strings that the API can also return as a
+// single string, such as authors and owners
+type nugetStrings []string
+
+func (s *nugetStrings) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = nil
+		if value != "" {
+			*s = nugetStrings{value}
+		}
+
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	*s = values
+	return nil
+}
+
+// nugetNuspec is the package manifest served by the flat container
+// API (sample): https://api.nuget.org/v3-flatcontainer/newtonsoft.json/13.0.3/newtonsoft.json.nuspec
+type nugetNuspec struct {
+	XMLName  xml.Name            `xml:"package"`
+	Metadata nugetNuspecMetadata `xml:"metadata"`
+}
+
+type nugetNuspecMetadata struct {
+	ID           string                  `xml:"id"`
+	Version      string                  `xml:"version"`
+	Repository   nugetNuspecRepository   `xml:"repository"`
+	Dependencies nugetNuspecDependencies `xml:"dependencies"`
+}
+
+type nugetNuspecRepository struct {
+	Type string `xml:"type,attr"`
+	URL  string `xml:"url,attr"`
+}
+
+// Dependencies are either grouped by target framework or, in older
+// packages, listed without groups for all frameworks
+type nugetNuspecDependencies struct {
+	Groups       []nugetNuspecDependencyGroup `xml:"group"`
+	Dependencies []nugetNuspecDependency      `xml:"dependency"`
+}
+
+type nugetNuspecDependencyGroup struct {
+	TargetFramework string                  `xml:"targetFramework,attr"`
+	Dependencies    []nugetNuspecDependency `xml:"dependency"`
+}
+
+type nugetNuspecDependency struct {
+	ID string `xml:"id,attr"`
+
+	// Version range such as "13.0.1" (minimum inclusive) or "[1.0,2.0)"
+	Version string `xml:"version,attr"`
+}
@@ -0,0 +1,93 @@
+package packageregistry
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NuGet V3 API Endpoints
+// Docs: https://learn.microsoft.com/en-us/nuget/api/overview
+//
+// The service index lists the base URLs of the resources of a feed, which
+// can be hosted on other domains than the index
+
+const (
+	nugetDefaultServiceIndexURL = "https://api.nuget.org/v3/index.json"
+
+	// Max page size of the search service is 1000
+	nugetSearchPageSize = 100
+)
+
+// Resource types of the service index in order of preference. Newer
+// registration hives include SemVer 2.0.0 packages
+var (
+	nugetRegistrationsResourceTypes = []string{
+		"RegistrationsBaseUrl/3.6.0",
+		"RegistrationsBaseUrl/3.4.0",
+		"RegistrationsBaseUrl/3.0.0-rc",
+		"RegistrationsBaseUrl/3.0.0-beta",
+		"RegistrationsBaseUrl",
+	}
+
+	nugetPackageBaseAddressResourceTypes = []string{
+		"PackageBaseAddress/3.0.0",
+	}
+
+	nugetSearchResourceTypes = []string{
+		"SearchQueryService/3.5.0",
+		"SearchQueryService/3.0.0-rc",
+		"SearchQueryService/3.0.0-beta",
+		"SearchQueryService",
+	}
+)
+
+// Registration index listing all versions of a package with their metadata
+func nugetAPIEndpointRegistrationIndexURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/%s/index.json", baseURL, url.PathEscape(strings.ToLower(packageName)))
+}
+
+// Package manifest of a version in the flat container (PackageBaseAddress)
+func nugetAPIEndpointNuspecURL(baseURL, packageName, version string) string {
+	id := url.PathEscape(strings.ToLower(packageName))
+	return fmt.Sprintf("%s/%s/%s/%s.nuspec", baseURL, id, url.PathEscape(nugetNormalizeVersion(version)), id)
+}
+
+// Search including prerelease and SemVer 2.0.0 packages. The query supports
+// field filters such as packageid:Newtonsoft.Json or owner:microsoft
+func nugetAPIEndpointSearchURL(baseURL, query string, skip int) string {
+	return fmt.Sprintf("%s?q=%s&skip=%d&take=%d&prerelease=true&semVerLevel=2.0.0",
+		baseURL, url.QueryEscape(query), skip, nugetSearchPageSize)
+}
+
+// nugetNormalizeVersion normalizes a version the way the flat container
+// expects it in URLs: build metadata is removed, leading zeros are trimmed,
+// a zero fourth part is dropped and the version is lowercased
+// Docs: https://learn.microsoft.com/en-us/nuget/concepts/package-versioning#normalized-version-numbers
+func nugetNormalizeVersion(version string) string {
+	version, _, _ = strings.Cut(strings.TrimSpace(version), "+")
+	release, prerelease, hasPrerelease := strings.Cut(version, "-")
+
+	parts := strings.Split(release, ".")
+	for i, part := range parts {
+		if n, err := strconv.ParseUint(part, 10, 64); err == nil {
+			parts[i] = strconv.FormatUint(n, 10)
+		}
+	}
+
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	if len(parts) == 4 && parts[3] == "0" {
+		parts = parts[:3]
+	}
+
+	normalized := strings.Join(parts, ".")
+	if hasPrerelease {
+		normalized += "-" + prerelease
+	}
+
+	return strings.ToLower(normalized)
+}
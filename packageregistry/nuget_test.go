@@ -0,0 +1,391 @@
+package packageregistry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nugetTestFeed is a fake NuGet V3 feed whose search service is hosted on
+// another server, like nuget.org
+type nugetTestFeed struct {
+	feed   *httptest.Server
+	search *httptest.Server
+
+	mu             sync.Mutex
+	authorizations map[string][]string
+}
+
+func newNugetTestFeed(t *testing.T) *nugetTestFeed {
+	tf := &nugetTestFeed{authorizations: map[string][]string{}}
+
+	tf.feed = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tf.record("feed", r)
+
+		switch r.URL.Path {
+		case "/v3/index.json":
+			_, _ = w.Write([]byte(`{
+				"version": "3.0.0",
+				"resources": [
+					{"@id": "` + tf.feed.URL + `/v3/registration-legacy/", "@type": "RegistrationsBaseUrl"},
+					{"@id": "` + tf.feed.URL + `/v3/registration/", "@type": "RegistrationsBaseUrl/3.6.0"},
+					{"@id": "` + tf.feed.URL + `/v3/flatcontainer/", "@type": "PackageBaseAddress/3.0.0"},
+					{"@id": "` + tf.search.URL + `/query", "@type": "SearchQueryService"}
+				]
+			}`))
+		case "/v3/registration/demo.package/index.json":
+			_, _ = w.Write([]byte(`{
+				"count": 2,
+				"items": [
+					{
+						"@id": "` + tf.feed.URL + `/v3/registration/demo.package/index.json#page/1.0.0/1.1.0",
+						"count": 2,
+						"lower": "1.0.0",
+						"upper": "1.1.0",
+						"items": [
+							{"catalogEntry": {"id": "Demo.Package", "version": "1.0.0", "published": "2023-01-10T10:00:00Z", "listed": true}},
+							{"catalogEntry": {"id": "Demo.Package", "version": "1.1.0", "published": "1900-01-01T00:00:00Z", "listed": false}}
+						]
+					},
+					{
+						"@id": "` + tf.feed.URL + `/v3/registration/demo.package/page/1.2.0/2.0.0-beta.1.json",
+						"count": 2,
+						"lower": "1.2.0",
+						"upper": "2.0.0-beta.1"
+					}
+				]
+			}`))
+		case "/v3/registration/demo.package/page/1.2.0/2.0.0-beta.1.json":
+			_, _ = w.Write([]byte(`{
+				"count": 2,
+				"items": [
+					{"catalogEntry": {
+						"id": "Demo.Package",
+						"version": "1.2.0",
+						"description": "A demo package",
+						"authors": "Example Ltd",
+						"published": "2024-03-01T10:00:00Z",
+						"listed": true
+					}},
+					{"catalogEntry": {"id": "Demo.Package", "version": "2.0.0-beta.1", "published": "2024-06-01T10:00:00Z"}}
+				]
+			}`))
+		case "/v3/flatcontainer/demo.package/1.2.0/demo.package.nuspec":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+				<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+					<metadata>
+						<id>Demo.Package</id>
+						<version>1.2.0</version>
+						<repository type="git" url="https://github.com/example/demo.package.git" />
+						<dependencies>
+							<group targetFramework="net8.0">
+								<dependency id="Newtonsoft.Json" version="13.0.1" exclude="Build,Analyzers" />
+							</group>
+							<group targetFramework=".NETStandard2.0">
+								<dependency id="Newtonsoft.Json" version="12.0.3" />
+								<dependency id="System.Text.Json" version="[6.0.0, )" />
+							</group>
+							<group targetFramework="net472" />
+						</dependencies>
+					</metadata>
+				</package>`))
+		case "/v3/flatcontainer/demo.package/1.0.0/demo.package.nuspec":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+				<package>
+					<metadata>
+						<id>Demo.Package</id>
+						<version>1.0</version>
+						<dependencies>
+							<dependency id="Legacy.Dependency" version="[1.0,2.0)" />
+						</dependencies>
+					</metadata>
+				</package>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(tf.feed.Close)
+
+	tf.search = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tf.record("search", r)
+
+		assert.Equal(t, "true", r.URL.Query().Get("prerelease"))
+		assert.Equal(t, "2.0.0", r.URL.Query().Get("semVerLevel"))
+
+		demoPackage := `{
+			"id": "Demo.Package",
+			"version": "2.0.0-beta.1",
+			"description": "A demo package",
+			"authors": ["Example Ltd"],
+			"owners": ["example"],
+			"totalDownloads": 1234,
+			"verified": true,
+			"versions": [{"version": "1.0.0", "downloads": 1000}, {"version": "2.0.0-beta.1", "downloads": 234}]
+		}`
+
+		switch strings.ToLower(r.URL.Query().Get("q")) {
+		case "packageid:demo.package":
+			_, _ = w.Write([]byte(`{"totalHits": 1, "data": [` + demoPackage + `]}`))
+		case "owner:example":
+			_, _ = w.Write([]byte(`{"totalHits": 2, "data": [` + demoPackage + `,
+				{"id": "Other.Package", "version": "1.0.0", "owners": "example-fork"}
+			]}`))
+		default:
+			_, _ = w.Write([]byte(`{"totalHits": 0, "data": []}`))
+		}
+	}))
+	t.Cleanup(tf.search.Close)
+
+	return tf
+}
+
+func (tf *nugetTestFeed) record(server string, r *http.Request) {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	tf.authorizations[server] = append(tf.authorizations[server], r.Header.Get("Authorization"))
+}
+
+func (tf *nugetTestFeed) adapter(t *testing.T) Client {
+	client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NUGET, &RegistryAdapterConfig{
+		NuGet: RegistryEndpoint{
+			BaseURL: tf.feed.URL + "/v3/index.json",
+			Auth:    RegistryAuth{BearerToken: "nuget_token"},
+		},
+	})
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestNugetGetPackage(t *testing.T) {
+	tf := newNugetTestFeed(t)
+
+	pd, err := tf.adapter(t).PackageDiscovery()
+	require.NoError(t, err)
+
+	t.Run("package metadata", func(t *testing.T) {
+		pkg, err := pd.GetPackage("demo.package")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Demo.Package", pkg.Name)
+		assert.Equal(t, "A demo package", pkg.Description)
+		assert.Equal(t, "https://github.com/example/demo.package", pkg.SourceRepositoryUrl)
+		assert.Equal(t, "Example Ltd", pkg.Author.Name)
+		assert.Equal(t, []Publisher{{Name: "example"}}, pkg.Maintainers)
+		assert.Equal(t, OptionalInt{Value: 1234, Valid: true}, pkg.Downloads)
+
+		// Unlisted versions are skipped and stable versions are preferred
+		assert.Equal(t, "1.2.0", pkg.LatestVersion)
+
+		versions := make([]string, 0, len(pkg.Versions))
+		for _, version := range pkg.Versions {
+			require.NotNil(t, version.PublishedAt)
+			versions = append(versions, version.Version)
+		}
+
+		assert.Equal(t, []string{"1.0.0", "1.2.0", "2.0.0-beta.1"}, versions)
+		assert.Equal(t, time.Date(2023, 1, 10, 10, 0, 0, 0, time.UTC), pkg.CreatedAt)
+		assert.Equal(t, time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), pkg.UpdatedAt)
+	})
+
+	t.Run("package not found", func(t *testing.T) {
+		_, err := pd.GetPackage("missing.package")
+		assert.ErrorIs(t, err, ErrPackageNotFound)
+	})
+
+	t.Run("auth is sent only to the feed", func(t *testing.T) {
+		tf.mu.Lock()
+		defer tf.mu.Unlock()
+
+		for _, authorization := range tf.authorizations["feed"] {
+			assert.Equal(t, "Bearer nuget_token", authorization)
+		}
+
+		for _, authorization := range tf.authorizations["search"] {
+			assert.Empty(t, authorization)
+		}
+
+		assert.NotEmpty(t, tf.authorizations["search"])
+	})
+}
+
+func TestNugetGetPackageDependencies(t *testing.T) {
+	tf := newNugetTestFeed(t)
+
+	pd, err := tf.adapter(t).PackageDiscovery()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		pkgName    string
+		pkgVersion string
+
+		expectedError        error
+		expectedDependencies []PackageDependencyInfo
+	}{
+		{
+			name:       "dependency groups",
+			pkgName:    "Demo.Package",
+			pkgVersion: "1.2.0",
+			expectedDependencies: []PackageDependencyInfo{
+				{Name: "Newtonsoft.Json", VersionSpec: "13.0.1", TargetFramework: "net8.0"},
+				{Name: "Newtonsoft.Json", VersionSpec: "12.0.3", TargetFramework: ".NETStandard2.0"},
+				{Name: "System.Text.Json", VersionSpec: "[6.0.0, )", TargetFramework: ".NETStandard2.0"},
+			},
+		},
+		{
+			name:       "dependencies without groups and a version to normalize",
+			pkgName:    "Demo.Package",
+			pkgVersion: "1.0",
+			expectedDependencies: []PackageDependencyInfo{
+				{Name: "Legacy.Dependency", VersionSpec: "[1.0,2.0)"},
+			},
+		},
+		{
+			name:          "version not found",
+			pkgName:       "Demo.Package",
+			pkgVersion:    "0.0.1",
+			expectedError: ErrPackageNotFound,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			dependencies, err := pd.GetPackageDependencies(test.pkgName, test.pkgVersion)
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedDependencies, dependencies.Dependencies)
+				assert.Empty(t, dependencies.DevDependencies)
+			}
+		})
+	}
+}
+
+func TestNugetGetPackageDownloadStats(t *testing.T) {
+	tf := newNugetTestFeed(t)
+
+	pd, err := tf.adapter(t).PackageDiscovery()
+	require.NoError(t, err)
+
+	stats, err := pd.GetPackageDownloadStats("Demo.Package")
+	require.NoError(t, err)
+	assert.Equal(t, DownloadStats{Total: 1234}, stats)
+
+	_, err = pd.GetPackageDownloadStats("missing.package")
+	assert.ErrorIs(t, err, ErrPackageNotFound)
+}
+
+func TestNugetPublisherDiscovery(t *testing.T) {
+	tf := newNugetTestFeed(t)
+
+	pd, err := tf.adapter(t).PublisherDiscovery()
+	require.NoError(t, err)
+
+	t.Run("package publishers", func(t *testing.T) {
+		publisherInfo, err := pd.GetPackagePublisher(&packagev1.PackageVersion{
+			Package: &packagev1.Package{
+				Ecosystem: packagev1.Ecosystem_ECOSYSTEM_NUGET,
+				Name:      "Demo.Package",
+			},
+			Version: "1.2.0",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Publisher{{
+			Name:               "example",
+			VerificationStatus: &PublisherVerificationStatus{IsVerified: true},
+		}}, publisherInfo.Publishers)
+	})
+
+	t.Run("publisher packages", func(t *testing.T) {
+		packages, err := pd.GetPublisherPackages(Publisher{Name: "example"})
+		require.NoError(t, err)
+
+		require.Len(t, packages, 1)
+		assert.Equal(t, "Demo.Package", packages[0].Name)
+		assert.Equal(t, "2.0.0-beta.1", packages[0].LatestVersion)
+		assert.Len(t, packages[0].Versions, 2)
+	})
+
+	t.Run("publisher without packages", func(t *testing.T) {
+		_, err := pd.GetPublisherPackages(Publisher{Name: "nobody"})
+		assert.ErrorIs(t, err, ErrNoPackagesFound)
+	})
+}
+
+func TestNugetServiceIndexHonorsCallerDeadline(t *testing.T) {
+	tf := newNugetTestFeed(t)
+
+	var requests atomic.Int32
+	release := make(chan struct{})
+
+	// Holds the first service index request until released
+	slowIndex := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-release
+		}
+
+		http.Redirect(w, r, tf.feed.URL+"/v3/index.json", http.StatusFound)
+	}))
+	defer slowIndex.Close()
+
+	client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NUGET, &RegistryAdapterConfig{
+		NuGet: RegistryEndpoint{BaseURL: slowIndex.URL + "/v3/index.json"},
+	})
+	require.NoError(t, err)
+
+	pd, err := client.PackageDiscovery()
+	require.NoError(t, err)
+
+	cpd, ok := pd.(ContextPackageDiscovery)
+	require.True(t, ok)
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := cpd.GetPackageDownloadStatsContext(context.Background(), "Demo.Package")
+		slow <- err
+	}()
+
+	assert.Eventually(t, func() bool { return requests.Load() == 1 }, time.Second, time.Millisecond)
+
+	// A caller with a deadline does not wait behind the slow request
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	stats, err := cpd.GetPackageDownloadStatsContext(ctx, "Demo.Package")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234), stats.Total)
+
+	close(release)
+	assert.NoError(t, <-slow)
+}
+
+func TestNugetNormalizeVersion(t *testing.T) {
+	cases := map[string]string{
+		"1.0":                  "1.0.0",
+		"1.0.0":                "1.0.0",
+		"01.02.03":             "1.2.3",
+		"1.2.3.0":              "1.2.3",
+		"1.2.3.4":              "1.2.3.4",
+		"2.0.0-Beta.1":         "2.0.0-beta.1",
+		"1.0.0-rc.1+build.123": "1.0.0-rc.1",
+	}
+
+	for version, expected := range cases {
+		t.Run(version, func(t *testing.T) {
+			assert.Equal(t, expected, nugetNormalizeVersion(version))
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package packageregistry
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -25,12 +29,115 @@ func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error
 	return t.base.RoundTrip(req)
 }
 
+// defaultHTTPTimeout bounds requests made with a context without a deadline
+const defaultHTTPTimeout = 10 * time.Second
+
 // httpClient returns a new http.Client with our opinionated
 // defaults. If required, we need to implement a package specific
 // configuration / fine tuning.
 func httpClient() *http.Client {
 	return &http.Client{
-		Timeout:   10 * time.Second,
+		Timeout:   defaultHTTPTimeout,
 		Transport: &userAgentTransport{base: http.DefaultTransport},
 	}
 }
+
+// registryEndpoint is a registry API with the client and credentials used
+// for requests to it
+type registryEndpoint struct {
+	baseURL string
+	auth    RegistryAuth
+	client  *http.Client
+}
+
+// newRegistryEndpoint resolves the endpoint of a registry configured by
+// config, using defaultBaseURL when it is not set
+func newRegistryEndpoint(config *RegistryAdapterConfig, endpoint RegistryEndpoint, defaultBaseURL string) (registryEndpoint, error) {
+	baseURL := endpoint.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	if err := validateRegistryURL(baseURL); err != nil {
+		return registryEndpoint{}, err
+	}
+
+	if endpoint.Auth.BearerToken != "" && (endpoint.Auth.Username != "" || endpoint.Auth.Password != "") {
+		return registryEndpoint{}, fmt.Errorf("registry %s: bearer token and basic auth are mutually exclusive", baseURL)
+	}
+
+	return registryEndpoint{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		auth:    endpoint.Auth,
+		client:  config.HTTPClient,
+	}, nil
+}
+
+func validateRegistryURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid registry url %q: %w", baseURL, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid registry url %q: must be an absolute http or https url", baseURL)
+	}
+
+	return nil
+}
+
+// get sends a GET request for requestURL, which must be built from the
+// base URL of the endpoint
+func (e registryEndpoint) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	return httpGet(ctx, e.client, e.auth, requestURL)
+}
+
+// httpGet sends a GET request bound to ctx with auth. A nil client uses the
+// default client, whose timeout applies only when ctx has no deadline so
+// that callers can allow slower registries. Errors wrap both
+// ErrFailedToFetchPackage and the cause, such as context.Canceled
+func httpGet(ctx context.Context, client *http.Client, auth RegistryAuth, requestURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchPackage, err)
+	}
+
+	switch {
+	case auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+auth.BearerToken)
+	case auth.Username != "" || auth.Password != "":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	if client == nil {
+		client = httpClient()
+		if _, ok := ctx.Deadline(); ok {
+			client.Timeout = 0
+		}
+	} else {
+		// Custom clients are used as is, so only the user agent is added
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchPackage, err)
+	}
+
+	return res, nil
+}
+
+// sameOrigin reports whether requestURL has the scheme and host of baseURL
+func sameOrigin(baseURL, requestURL string) bool {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+
+	target, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(base.Scheme, target.Scheme) && strings.EqualFold(base.Host, target.Host)
+}
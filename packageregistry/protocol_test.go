@@ -0,0 +1,395 @@
+package packageregistry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
+	"github.com/safedep/dry/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, userAgent, r.Header.Get("User-Agent"))
+
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	t.Run("deadline reaches the request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := httpGet(ctx, nil, RegistryAuth{}, server.URL)
+		assert.ErrorIs(t, err, ErrFailedToFetchPackage)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("deadline overrides default timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultHTTPTimeout+time.Second)
+		defer cancel()
+
+		res, err := httpGet(ctx, nil, RegistryAuth{}, server.URL)
+		require.NoError(t, err)
+
+		_ = res.Body.Close()
+	})
+}
+
+func TestHTTPGetAuth(t *testing.T) {
+	cases := []struct {
+		name          string
+		auth          RegistryAuth
+		authorization string
+	}{
+		{
+			name:          "bearer token",
+			auth:          RegistryAuth{BearerToken: "npm_token"},
+			authorization: "Bearer npm_token",
+		},
+		{
+			name:          "basic auth",
+			auth:          RegistryAuth{Username: "user", Password: "secret"},
+			authorization: "Basic dXNlcjpzZWNyZXQ=",
+		},
+		{
+			name: "no auth",
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.authorization, r.Header.Get("Authorization"))
+				assert.Equal(t, userAgent, r.Header.Get("User-Agent"))
+			}))
+			defer server.Close()
+
+			for _, client := range []*http.Client{nil, server.Client()} {
+				res, err := httpGet(context.Background(), client, test.auth, server.URL)
+				require.NoError(t, err)
+
+				_ = res.Body.Close()
+			}
+		})
+	}
+}
+
+func TestRegistryEndpoints(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/npm/demo":
+			_, _ = w.Write([]byte(`{"name":"demo","versions":{"1.0.0":{"version":"1.0.0"}},"dist-tags":{"latest":"1.0.0"}}`))
+		case "/npm-downloads/downloads/point/last-year/demo":
+			_, _ = w.Write([]byte(`{"downloads":42}`))
+		case "/go-mirror/example.com/demo/@latest":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/go-proxy/example.com/demo/@latest":
+			_, _ = w.Write([]byte(`{"Version":"v1.0.0"}`))
+		case "/go-proxy/example.com/demo/@v/list":
+			_, _ = w.Write([]byte("v1.0.0\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("npm", func(t *testing.T) {
+		requests = nil
+
+		client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NPM, &RegistryAdapterConfig{
+			HTTPClient:   server.Client(),
+			Npm:          RegistryEndpoint{BaseURL: server.URL + "/npm/", Auth: RegistryAuth{BearerToken: "npm_token"}},
+			NpmDownloads: RegistryEndpoint{BaseURL: server.URL + "/npm-downloads"},
+		})
+		require.NoError(t, err)
+
+		pd, err := client.PackageDiscovery()
+		require.NoError(t, err)
+
+		pkg, err := pd.GetPackage("demo")
+		require.NoError(t, err)
+
+		assert.Equal(t, "1.0.0", pkg.LatestVersion)
+		assert.Equal(t, uint64(42), pkg.Downloads.Value)
+
+		// Credentials are only sent to the endpoint they are configured for
+		assert.Equal(t, []string{
+			"/npm/demo Bearer npm_token",
+			"/npm-downloads/downloads/point/last-year/demo ",
+		}, requests)
+	})
+
+	t.Run("npm downloads are optional", func(t *testing.T) {
+		cases := []struct {
+			name         string
+			npmDownloads RegistryEndpoint
+			requests     []string
+		}{
+			{
+				name:     "private registry without downloads API",
+				requests: []string{"/npm/demo "},
+			},
+			{
+				name:         "downloads API without the package",
+				npmDownloads: RegistryEndpoint{BaseURL: server.URL + "/missing"},
+				requests:     []string{"/npm/demo ", "/missing/downloads/point/last-year/demo "},
+			},
+		}
+
+		for _, test := range cases {
+			t.Run(test.name, func(t *testing.T) {
+				requests = nil
+
+				client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NPM, &RegistryAdapterConfig{
+					HTTPClient:   server.Client(),
+					Npm:          RegistryEndpoint{BaseURL: server.URL + "/npm"},
+					NpmDownloads: test.npmDownloads,
+				})
+				require.NoError(t, err)
+
+				pd, err := client.PackageDiscovery()
+				require.NoError(t, err)
+
+				pkg, err := pd.GetPackage("demo")
+				require.NoError(t, err)
+
+				assert.Equal(t, "1.0.0", pkg.LatestVersion)
+				assert.False(t, pkg.Downloads.Valid)
+				assert.Equal(t, test.requests, requests)
+			})
+		}
+
+		client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NPM, &RegistryAdapterConfig{
+			Npm: RegistryEndpoint{BaseURL: server.URL + "/npm"},
+		})
+		require.NoError(t, err)
+
+		pd, err := client.PackageDiscovery()
+		require.NoError(t, err)
+
+		_, err = pd.GetPackageDownloadStats("demo")
+		assert.ErrorIs(t, err, ErrOperationNotSupported)
+	})
+
+	t.Run("go proxy list", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			goProxy  string
+			err      error
+			requests []string
+		}{
+			{
+				name:    "comma falls back on not found",
+				goProxy: server.URL + "/missing," + server.URL + "/go-proxy",
+				requests: []string{
+					"/missing/example.com/demo/@latest ",
+					"/go-proxy/example.com/demo/@latest ",
+					"/missing/example.com/demo/@v/list ",
+					"/go-proxy/example.com/demo/@v/list ",
+				},
+			},
+			{
+				name:    "comma does not fall back on errors",
+				goProxy: server.URL + "/go-mirror," + server.URL + "/go-proxy",
+				err:     ErrFailedToFetchPackage,
+				requests: []string{
+					"/go-mirror/example.com/demo/@latest ",
+				},
+			},
+			{
+				name:    "pipe falls back on errors",
+				goProxy: server.URL + "/go-mirror|" + server.URL + "/go-proxy|direct",
+				requests: []string{
+					"/go-mirror/example.com/demo/@latest ",
+					"/go-proxy/example.com/demo/@latest ",
+					"/go-mirror/example.com/demo/@v/list ",
+					"/go-proxy/example.com/demo/@v/list ",
+				},
+			},
+			{
+				name:    "off disables lookups",
+				goProxy: "off",
+				err:     ErrFailedToFetchPackage,
+			},
+		}
+
+		for _, test := range cases {
+			t.Run(test.name, func(t *testing.T) {
+				requests = nil
+
+				client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_GO, &RegistryAdapterConfig{
+					GoProxy: RegistryEndpoint{BaseURL: test.goProxy},
+				})
+				require.NoError(t, err)
+
+				pd, err := client.PackageDiscovery()
+				require.NoError(t, err)
+
+				pkg, err := pd.GetPackage("example.com/demo")
+				if test.err != nil {
+					assert.ErrorIs(t, err, test.err)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, "v1.0.0", pkg.LatestVersion)
+					assert.Len(t, pkg.Versions, 1)
+				}
+
+				assert.Equal(t, test.requests, requests)
+			})
+		}
+	})
+}
+
+func TestGoProxyListAuth(t *testing.T) {
+	var requests []string
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Host+r.URL.Path+" "+r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/go-proxy/example.com/demo/@latest":
+			_, _ = w.Write([]byte(`{"Version":"v1.0.0"}`))
+		case "/go-proxy/example.com/demo/@v/list":
+			_, _ = w.Write([]byte("v1.0.0\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	private := httptest.NewServer(http.HandlerFunc(handler))
+	defer private.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(handler))
+	defer public.Close()
+
+	client, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_GO, &RegistryAdapterConfig{
+		GoProxy: RegistryEndpoint{
+			BaseURL: private.URL + "/missing," + private.URL + "/other," + public.URL + "/go-proxy",
+			Auth:    RegistryAuth{BearerToken: "go_token"},
+		},
+	})
+	require.NoError(t, err)
+
+	pd, err := client.PackageDiscovery()
+	require.NoError(t, err)
+
+	_, err = pd.GetPackage("example.com/demo")
+	require.NoError(t, err)
+
+	privateHost := strings.TrimPrefix(private.URL, "http://")
+	publicHost := strings.TrimPrefix(public.URL, "http://")
+
+	// The fallback proxy on another host receives no credentials
+	assert.Equal(t, []string{
+		privateHost + "/missing/example.com/demo/@latest Bearer go_token",
+		privateHost + "/other/example.com/demo/@latest Bearer go_token",
+		publicHost + "/go-proxy/example.com/demo/@latest ",
+		privateHost + "/missing/example.com/demo/@v/list Bearer go_token",
+		privateHost + "/other/example.com/demo/@v/list Bearer go_token",
+		publicHost + "/go-proxy/example.com/demo/@v/list ",
+	}, requests)
+}
+
+func TestRegistryAdapterConfigValidation(t *testing.T) {
+	cases := []struct {
+		name      string
+		ecosystem packagev1.Ecosystem
+		config    RegistryAdapterConfig
+	}{
+		{
+			name:      "relative url",
+			ecosystem: packagev1.Ecosystem_ECOSYSTEM_PYPI,
+			config:    RegistryAdapterConfig{PyPI: RegistryEndpoint{BaseURL: "devpi.example.com/root/pypi"}},
+		},
+		{
+			name:      "unsupported scheme",
+			ecosystem: packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+			config:    RegistryAdapterConfig{Maven: RegistryEndpoint{BaseURL: "ftp://nexus.example.com/maven2"}},
+		},
+		{
+			name:      "bearer token and basic auth",
+			ecosystem: packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+			config: RegistryAdapterConfig{RubyGems: RegistryEndpoint{
+				BaseURL: "https://gems.example.com",
+				Auth:    RegistryAuth{BearerToken: "token", Username: "user"},
+			}},
+		},
+		{
+			name:      "go proxy list without proxies",
+			ecosystem: packagev1.Ecosystem_ECOSYSTEM_GO,
+			config:    RegistryAdapterConfig{GoProxy: RegistryEndpoint{BaseURL: "direct"}},
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRegistryAdapter(test.ecosystem, &test.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDiscoveryContextCancellation(t *testing.T) {
+	githubClient, err := adapters.NewGithubClient(adapters.GitHubClientConfig{})
+	require.NoError(t, err)
+
+	ecosystems := []packagev1.Ecosystem{
+		packagev1.Ecosystem_ECOSYSTEM_NPM,
+		packagev1.Ecosystem_ECOSYSTEM_PYPI,
+		packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS,
+		packagev1.Ecosystem_ECOSYSTEM_GO,
+		packagev1.Ecosystem_ECOSYSTEM_MAVEN,
+		packagev1.Ecosystem_ECOSYSTEM_CARGO,
+		packagev1.Ecosystem_ECOSYSTEM_NUGET,
+		packagev1.Ecosystem_ECOSYSTEM_GITHUB_REPOSITORY,
+	}
+
+	packageNames := map[packagev1.Ecosystem]string{
+		packagev1.Ecosystem_ECOSYSTEM_MAVEN:             "org.apache.commons:commons-lang3",
+		packagev1.Ecosystem_ECOSYSTEM_GITHUB_REPOSITORY: "safedep/vet",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, ecosystem := range ecosystems {
+		t.Run(ecosystem.String(), func(t *testing.T) {
+			client, err := NewRegistryAdapter(ecosystem, &RegistryAdapterConfig{GitHubClient: githubClient})
+			require.NoError(t, err)
+
+			pd, err := client.PackageDiscovery()
+			require.NoError(t, err)
+
+			packageName, ok := packageNames[ecosystem]
+			if !ok {
+				packageName = "express"
+			}
+
+			cpd, ok := pd.(ContextPackageDiscovery)
+			require.True(t, ok)
+
+			_, err = cpd.GetPackageContext(ctx, packageName)
+			assert.ErrorIs(t, err, context.Canceled)
+
+			publisherDiscovery, err := client.PublisherDiscovery()
+			require.NoError(t, err)
+
+			_, ok = publisherDiscovery.(ContextPublisherDiscovery)
+			assert.True(t, ok)
+		})
+	}
+}
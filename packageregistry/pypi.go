@@ -1,6 +1,7 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/mail"
@@ -9,53 +10,61 @@ import (
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 )
 
-type pypiAdapter struct{}
+type pypiAdapter struct {
+	registry registryEndpoint
+}
 
 // Verify that pypiAdapter implements the Client interface
 var _ Client = (*pypiAdapter)(nil)
 
-type pypiPublisherDiscovery struct{}
-type pypiPackageDiscovery struct{}
+type pypiPublisherDiscovery struct {
+	registry registryEndpoint
+}
+
+type pypiPackageDiscovery struct {
+	registry registryEndpoint
+}
+
+var (
+	_ ContextPublisherDiscovery = (*pypiPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*pypiPackageDiscovery)(nil)
+)
 
 // Verify that pypiAdapter implements the Client interface
 var _ Client = (*pypiAdapter)(nil)
 
 func NewPypiAdapter() (Client, error) {
-	return &pypiAdapter{}, nil
+	return newPypiAdapter(&RegistryAdapterConfig{})
+}
+
+func newPypiAdapter(config *RegistryAdapterConfig) (Client, error) {
+	registry, err := newRegistryEndpoint(config, config.PyPI, pypiDefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pypiAdapter{registry: registry}, nil
 }
 
 func (na *pypiAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
-	return &pypiPublisherDiscovery{}, nil
+	return &pypiPublisherDiscovery{registry: na.registry}, nil
 }
 
 func (na *pypiAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &pypiPackageDiscovery{}, nil
+	return &pypiPackageDiscovery{registry: na.registry}, nil
 }
 
 func (np *pypiPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return np.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (np *pypiPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	packageName := packageVersion.GetPackage().GetName()
 	version := packageVersion.GetVersion()
 
-	packageURL := pypiAPIEndpointPackageWithVersionURL(packageName, version)
-	res, err := httpClient().Get(packageURL)
-	if err != nil {
-		return nil, ErrFailedToFetchPackage
-	}
-
-	if res.StatusCode == 404 {
-		return nil, ErrPackageNotFound
-	}
-
-	if res.StatusCode != 200 {
-		return nil, ErrFailedToFetchPackage
-	}
-
-	defer res.Body.Close()
-
-	var pypipkg pypiPackage
-	err = json.NewDecoder(res.Body).Decode(&pypipkg)
+	pypipkg, err := pypiGetPackageVersion(ctx, np.registry, packageName, version)
 	if err != nil {
-		return nil, ErrFailedToParsePackage
+		return nil, err
 	}
 
 	author := parsePypiAuthor(
@@ -74,20 +83,41 @@ func (np *pypiPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.
 
 // Pypi does not support getting packages by publisher
 func (np *pypiPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
+	return np.GetPublisherPackagesContext(context.Background(), publisher)
+}
+
+func (np *pypiPublisherDiscovery) GetPublisherPackagesContext(_ context.Context, publisher Publisher) ([]*Package, error) {
 	return nil, ErrNoPackagesFound
 }
 
 func (np *pypiPackageDiscovery) GetPackageDependencies(packageName string,
 	packageVersion string) (*PackageDependencyList, error) {
-	return nil, fmt.Errorf("dependency resolution is not supported for PyPI adapter")
+	return np.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
+}
+
+// GetPackageDependenciesContext returns the dependencies declared in the
+// requires_dist metadata of the package version. Dependencies required only
+// by extras, such as dev or test, are returned as dev dependencies
+func (np *pypiPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName string,
+	packageVersion string) (*PackageDependencyList, error) {
+	pypipkg, err := pypiGetPackageVersion(ctx, np.registry, packageName, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return pypiParseRequiresDist(pypipkg.Info.RequiresDist), nil
 }
 
 func (np *pypiPackageDiscovery) GetPackage(packageName string) (*Package, error) {
-	url := pypiAPIEndpointPackageURL(packageName)
+	return np.GetPackageContext(context.Background(), packageName)
+}
+
+func (np *pypiPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
+	url := pypiAPIEndpointPackageURL(np.registry.baseURL, packageName)
 
-	res, err := httpClient().Get(url)
+	res, err := np.registry.get(ctx, url)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 
 	if res.StatusCode == 404 {
@@ -155,9 +185,38 @@ func (np *pypiPackageDiscovery) GetPackage(packageName string) (*Package, error)
 }
 
 func (np *pypiPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return np.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (np *pypiPackageDiscovery) GetPackageDownloadStatsContext(_ context.Context, packageName string) (DownloadStats, error) {
 	return DownloadStats{}, fmt.Errorf("download stats is not supported for PyPI adapter")
 }
 
+func pypiGetPackageVersion(ctx context.Context, registry registryEndpoint, packageName, version string) (*pypiPackage, error) {
+	packageURL := pypiAPIEndpointPackageWithVersionURL(registry.baseURL, packageName, version)
+	res, err := registry.get(ctx, packageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, ErrPackageNotFound
+	}
+
+	if res.StatusCode != 200 {
+		return nil, ErrFailedToFetchPackage
+	}
+
+	var pypipkg pypiPackage
+	err = json.NewDecoder(res.Body).Decode(&pypipkg)
+	if err != nil {
+		return nil, ErrFailedToParsePackage
+	}
+
+	return &pypipkg, nil
+}
+
 func parsePypiAuthor(authorName, authorEmail string, splitEmailIntoName bool) Publisher {
 	name := strings.TrimSpace(authorName)
 	email := strings.TrimSpace(authorEmail)
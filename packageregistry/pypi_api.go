@@ -19,6 +19,9 @@ type pypiPackageInfo struct {
 	Maintainer      string          `json:"maintainer"`
 	MaintainerEmail string          `json:"maintainer_email"`
 	ProjectURLs     pypiProjectURLs `json:"project_urls"`
+
+	// PEP 508 requirements of the version, null when there are none
+	RequiresDist []string `json:"requires_dist"`
 }
 
 type pypiProjectURLs struct {
@@ -2,10 +2,12 @@ package packageregistry
 
 import "fmt"
 
-func pypiAPIEndpointPackageURL(packageName string) string {
-	return fmt.Sprintf("https://pypi.org/pypi/%s/json", packageName)
+const pypiDefaultBaseURL = "https://pypi.org/pypi"
+
+func pypiAPIEndpointPackageURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/%s/json", baseURL, packageName)
 }
 
-func pypiAPIEndpointPackageWithVersionURL(packageName, version string) string {
-	return fmt.Sprintf("https://pypi.org/pypi/%s/%s/json", packageName, version)
+func pypiAPIEndpointPackageWithVersionURL(baseURL, packageName, version string) string {
+	return fmt.Sprintf("%s/%s/%s/json", baseURL, packageName, version)
 }
@@ -0,0 +1,166 @@
+package packageregistry
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PEP 508 dependency specifiers as found in the requires_dist metadata
+// Docs: https://peps.python.org/pep-0508/
+//
+//	name [extras] (version specifiers | @ url) ; marker
+
+var (
+	pypiRequirementNameRegex = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)`)
+	pypiNameSeparatorRegex   = regexp.MustCompile(`[-_.]+`)
+	pypiMarkerStringRegex    = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	pypiMarkerExtraRegex     = regexp.MustCompile(`\bextra\b`)
+	pypiSpecifierRegex       = regexp.MustCompile(`^(~=|===|==|!=|<=|>=|<|>)[A-Za-z0-9.*+!_-]+$`)
+)
+
+// pypiRequirement is a parsed PEP 508 dependency specifier
+type pypiRequirement struct {
+	// Name normalized per PEP 503
+	Name string
+
+	// PEP 440 version specifiers separated by commas, or the URL of a
+	// direct reference. Empty when any version is allowed
+	VersionSpec string
+
+	// Required only when an extra of the package is installed
+	Extra bool
+}
+
+// pypiParseRequiresDist splits requirements into runtime dependencies and
+// dependencies of extras. Requirements with other markers, such as
+// python_version or sys_platform, are runtime dependencies since they are
+// installed on some platforms. Malformed requirements are skipped
+func pypiParseRequiresDist(requiresDist []string) *PackageDependencyList {
+	dependencies := make([]PackageDependencyInfo, 0)
+	devDependencies := make([]PackageDependencyInfo, 0)
+
+	seen := map[pypiRequirement]bool{}
+	for _, entry := range requiresDist {
+		requirement, ok := pypiParseRequirement(entry)
+		if !ok || seen[requirement] {
+			continue
+		}
+
+		seen[requirement] = true
+
+		info := PackageDependencyInfo{
+			Name:        requirement.Name,
+			VersionSpec: requirement.VersionSpec,
+		}
+
+		if requirement.Extra {
+			devDependencies = append(devDependencies, info)
+		} else {
+			dependencies = append(dependencies, info)
+		}
+	}
+
+	return &PackageDependencyList{
+		Dependencies:    dependencies,
+		DevDependencies: devDependencies,
+	}
+}
+
+func pypiParseRequirement(entry string) (pypiRequirement, bool) {
+	requirement, marker := pypiSplitMarker(entry)
+
+	requirement = strings.TrimSpace(requirement)
+	name := pypiRequirementNameRegex.FindString(requirement)
+	if name == "" {
+		return pypiRequirement{}, false
+	}
+
+	rest := strings.TrimSpace(requirement[len(name):])
+
+	// Extras of the dependency do not change the dependency itself
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return pypiRequirement{}, false
+		}
+
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	var versionSpec string
+	if url, ok := strings.CutPrefix(rest, "@"); ok {
+		versionSpec = strings.TrimSpace(url)
+		if versionSpec == "" {
+			return pypiRequirement{}, false
+		}
+	} else {
+		spec, ok := pypiNormalizeSpecifiers(rest)
+		if !ok {
+			return pypiRequirement{}, false
+		}
+
+		versionSpec = spec
+	}
+
+	return pypiRequirement{
+		Name:        pypiNormalizeName(name),
+		VersionSpec: versionSpec,
+		Extra:       pypiMarkerHasExtra(marker),
+	}, true
+}
+
+// pypiSplitMarker splits the environment marker from a requirement. URLs
+// can contain a semicolon, so the marker of a direct reference must be
+// separated by whitespace
+func pypiSplitMarker(entry string) (string, string) {
+	if !strings.Contains(entry, "@") {
+		requirement, marker, _ := strings.Cut(entry, ";")
+		return requirement, marker
+	}
+
+	for i := 1; i < len(entry); i++ {
+		if entry[i] == ';' && (entry[i-1] == ' ' || entry[i-1] == '\t') {
+			return entry[:i], entry[i+1:]
+		}
+	}
+
+	return entry, ""
+}
+
+// pypiNormalizeSpecifiers removes whitespace and the optional parentheses
+// around specifiers, such as "(<1.27, >=1.21.1)"
+func pypiNormalizeSpecifiers(spec string) (string, bool) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "(") {
+		if !strings.HasSuffix(spec, ")") {
+			return "", false
+		}
+
+		spec = spec[1 : len(spec)-1]
+	}
+
+	spec = strings.Join(strings.Fields(spec), "")
+	if spec == "" {
+		return "", true
+	}
+
+	for _, clause := range strings.Split(spec, ",") {
+		if !pypiSpecifierRegex.MatchString(clause) {
+			return "", false
+		}
+	}
+
+	return spec, true
+}
+
+// pypiNormalizeName normalizes a project name per PEP 503
+func pypiNormalizeName(name string) string {
+	return pypiNameSeparatorRegex.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// pypiMarkerHasExtra reports whether a marker refers to the extra variable,
+// ignoring quoted values such as "extra"
+func pypiMarkerHasExtra(marker string) bool {
+	marker = pypiMarkerStringRegex.ReplaceAllString(marker, "''")
+	return pypiMarkerExtraRegex.MatchString(marker)
+}
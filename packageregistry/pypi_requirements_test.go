@@ -0,0 +1,117 @@
+package packageregistry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPypiParseRequirement(t *testing.T) {
+	cases := []struct {
+		entry string
+
+		expectedOk          bool
+		expectedRequirement pypiRequirement
+	}{
+		{
+			entry:               "requests",
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "requests"},
+		},
+		{
+			entry:               "charset_normalizer<4,>=2",
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "charset-normalizer", VersionSpec: "<4,>=2"},
+		},
+		{
+			entry:               "urllib3 (<1.27, >=1.21.1)",
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "urllib3", VersionSpec: "<1.27,>=1.21.1"},
+		},
+		{
+			entry:               "Zope.Interface~=5.0",
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "zope-interface", VersionSpec: "~=5.0"},
+		},
+		{
+			entry:               `PySocks!=1.5.7,>=1.5.6; extra == "socks"`,
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "pysocks", VersionSpec: "!=1.5.7,>=1.5.6", Extra: true},
+		},
+		{
+			entry:               `pytest>=7 ; python_version >= "3.8" and 'dev' == extra`,
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "pytest", VersionSpec: ">=7", Extra: true},
+		},
+		{
+			entry:               `pywin32>=300; sys_platform == "win32"`,
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "pywin32", VersionSpec: ">=300"},
+		},
+		{
+			entry:               `typing-extensions; platform_release == "extra"`,
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "typing-extensions"},
+		},
+		{
+			entry:               "uvicorn[standard]==0.30.*",
+			expectedOk:          true,
+			expectedRequirement: pypiRequirement{Name: "uvicorn", VersionSpec: "==0.30.*"},
+		},
+		{
+			entry:      "pip @ https://example.com/pip.zip#sha1=da9234ee ; extra == 'vendored'",
+			expectedOk: true,
+			expectedRequirement: pypiRequirement{
+				Name:        "pip",
+				VersionSpec: "https://example.com/pip.zip#sha1=da9234ee",
+				Extra:       true,
+			},
+		},
+		{
+			entry:      ">=1.0",
+			expectedOk: false,
+		},
+		{
+			entry:      "foo[bar>=1.0",
+			expectedOk: false,
+		},
+		{
+			entry:      "foo latest",
+			expectedOk: false,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.entry, func(t *testing.T) {
+			requirement, ok := pypiParseRequirement(test.entry)
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expectedRequirement, requirement)
+		})
+	}
+}
+
+func TestPypiParseRequiresDist(t *testing.T) {
+	dependencies := pypiParseRequiresDist([]string{
+		"charset-normalizer<4,>=2",
+		"idna<4,>=2.5",
+		`PySocks!=1.5.7,>=1.5.6; extra == "socks"`,
+		`chardet<6,>=3.0.2; extra == "use-chardet-on-py3"`,
+		"IDNA<4,>=2.5",
+		"not a requirement",
+	})
+
+	assert.Equal(t, &PackageDependencyList{
+		Dependencies: []PackageDependencyInfo{
+			{Name: "charset-normalizer", VersionSpec: "<4,>=2"},
+			{Name: "idna", VersionSpec: "<4,>=2.5"},
+		},
+		DevDependencies: []PackageDependencyInfo{
+			{Name: "pysocks", VersionSpec: "!=1.5.7,>=1.5.6"},
+			{Name: "chardet", VersionSpec: "<6,>=3.0.2"},
+		},
+	}, dependencies)
+
+	empty := pypiParseRequiresDist(nil)
+	assert.Empty(t, empty.Dependencies)
+	assert.Empty(t, empty.DevDependencies)
+}
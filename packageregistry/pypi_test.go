@@ -8,6 +8,7 @@ import (
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPypiGetPublisher(t *testing.T) {
@@ -231,3 +232,33 @@ func TestPypiGetPackageLatestVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestPypiGetPackageDependencies(t *testing.T) {
+	adapter, err := NewPypiAdapter()
+	if err != nil {
+		t.Fatalf("failed to create package registry pypi adapter: %v", err)
+	}
+
+	pd, err := adapter.PackageDiscovery()
+	if err != nil {
+		t.Fatalf("failed to create package discovery client in pypi adapter")
+	}
+
+	dependencies, err := pd.GetPackageDependencies("requests", "2.32.3")
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []PackageDependencyInfo{
+		{Name: "charset-normalizer", VersionSpec: "<4,>=2"},
+		{Name: "idna", VersionSpec: "<4,>=2.5"},
+		{Name: "urllib3", VersionSpec: "<3,>=1.21.1"},
+		{Name: "certifi", VersionSpec: ">=2017.4.17"},
+	}, dependencies.Dependencies)
+
+	assert.ElementsMatch(t, []PackageDependencyInfo{
+		{Name: "pysocks", VersionSpec: "!=1.5.7,>=1.5.6"},
+		{Name: "chardet", VersionSpec: "<6,>=3.0.2"},
+	}, dependencies.DevDependencies)
+
+	_, err = pd.GetPackageDependencies("requests", "0.0.0-does-not-exist")
+	assert.ErrorIs(t, err, ErrPackageNotFound)
+}
@@ -1,7 +1,9 @@
 package packageregistry
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
@@ -36,6 +38,10 @@ type PackageDependencyInfo struct {
 	// Version spec of the dependency. Almost all package registries
 	// use a semver spec to denote a supported version range. Example: ~1.4.4
 	VersionSpec string `json:"version_spec"`
+
+	// TargetFramework the dependency is declared for. Only set by registries
+	// with framework specific dependencies such as NuGet. Example: net8.0
+	TargetFramework string `json:"target_framework,omitempty"`
 }
 
 type PackageDependencyList struct {
@@ -113,6 +119,23 @@ type PackageDiscovery interface {
 	GetPackageDownloadStats(packageName string) (DownloadStats, error)
 }
 
+// ContextPackageDiscovery is a PackageDiscovery whose requests to the package
+// registry are bound to a context. Cancelling the context or reaching its
+// deadline aborts in-flight requests. All adapters in this package return
+// a PackageDiscovery implementing it.
+type ContextPackageDiscovery interface {
+	PackageDiscovery
+
+	// GetPackageContext returns the package metadata for the given package name
+	GetPackageContext(ctx context.Context, packageName string) (*Package, error)
+
+	// GetPackageDependenciesContext returns the dependencies for the given package version.
+	GetPackageDependenciesContext(ctx context.Context, packageName string, packageVersion string) (*PackageDependencyList, error)
+
+	// GetPackageDownloadStatsContext returns the download stats for the given package.
+	GetPackageDownloadStatsContext(ctx context.Context, packageName string) (DownloadStats, error)
+}
+
 // Contract for implementing publisher discovery for a package registry.
 type PublisherDiscovery interface {
 	// GetPackagePublisher returns the publishers for the given package.
@@ -122,6 +145,19 @@ type PublisherDiscovery interface {
 	GetPublisherPackages(publisher Publisher) ([]*Package, error)
 }
 
+// ContextPublisherDiscovery is a PublisherDiscovery whose requests to the
+// package registry are bound to a context. All adapters in this package
+// return a PublisherDiscovery implementing it.
+type ContextPublisherDiscovery interface {
+	PublisherDiscovery
+
+	// GetPackagePublisherContext returns the publishers for the given package.
+	GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error)
+
+	// GetPublisherPackagesContext returns packages published by a given publisher.
+	GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error)
+}
+
 // Client is a contract for implementing package registry
 // clients for fetching various package and publisher metadata.
 type Client interface {
@@ -136,10 +172,73 @@ type Client interface {
 	PackageDiscovery() (PackageDiscovery, error)
 }
 
+// RegistryAuth holds the credentials sent to a registry. At most one of
+// bearer token and basic auth can be set.
+type RegistryAuth struct {
+	// BearerToken is sent in the Authorization header. The npm `_authToken`
+	// of an .npmrc is a bearer token.
+	BearerToken string
+
+	// Username and Password are sent using basic auth
+	Username string
+	Password string
+}
+
+// RegistryEndpoint is a registry API served by a private mirror or proxy
+// such as Artifactory, Nexus, Verdaccio or devpi.
+type RegistryEndpoint struct {
+	// BaseURL of the registry API. Empty uses the public registry.
+	BaseURL string
+
+	// Auth is sent only with requests to BaseURL
+	Auth RegistryAuth
+}
+
 // RegistryAdapterConfig is a configuration for the registry adapter.
 // Its optional and only required for certain ecosystems.
 type RegistryAdapterConfig struct {
 	GitHubClient *adapters.GithubClient
+
+	// HTTPClient is used for requests to registries. Defaults to a client
+	// with a 10 second timeout when the request context has no deadline.
+	HTTPClient *http.Client
+
+	// Npm registry. Default: https://registry.npmjs.org
+	Npm RegistryEndpoint
+
+	// NpmDownloads is the npm download counts API, which mirrors usually
+	// do not serve. Download counts are not fetched when Npm is set and
+	// NpmDownloads is not, so that private package names are not sent to
+	// the public API. Default: https://api.npmjs.org
+	NpmDownloads RegistryEndpoint
+
+	// PyPI JSON API. Default: https://pypi.org/pypi
+	PyPI RegistryEndpoint
+
+	// RubyGems API. Default: https://rubygems.org
+	RubyGems RegistryEndpoint
+
+	// Crates registry API. Default: https://crates.io/api/v1
+	Crates RegistryEndpoint
+
+	// GoProxy is a GOPROXY style list of module proxies. Proxies separated by
+	// a comma are tried when the previous one returns 404 or 410, while
+	// proxies separated by a pipe are tried after any error. "direct" ends
+	// the list and "off" disables lookups. Auth is sent only to proxies on
+	// the host of the first proxy, use credentials in the URLs of other
+	// proxies that need them.
+	// Default: https://proxy.golang.org
+	GoProxy RegistryEndpoint
+
+	// Maven repository serving POM files. Default: https://repo1.maven.org/maven2
+	Maven RegistryEndpoint
+
+	// MavenSearch is the Maven Central search API. Default: https://search.maven.org/solrsearch
+	MavenSearch RegistryEndpoint
+
+	// NuGet V3 service index. Auth is sent only to resources hosted on the
+	// same host as the index. Default: https://api.nuget.org/v3/index.json
+	NuGet RegistryEndpoint
 }
 
 // NewRegistryAdapter creates and returns a new registry adapter for the specified ecosystem.
@@ -159,6 +258,15 @@ type RegistryAdapterConfig struct {
 //		log.Fatalf("failed to create registry adapter: %v", err)
 //	}
 //
+// Example with a private npm registry:
+//
+//	client, err := packageregistry.NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_NPM, &packageregistry.RegistryAdapterConfig{
+//		Npm: packageregistry.RegistryEndpoint{
+//			BaseURL: "https://artifactory.example.com/api/npm/npm-remote",
+//			Auth:    packageregistry.RegistryAuth{BearerToken: npmAuthToken},
+//		},
+//	})
+//
 // Example with GitHub client:
 //
 //	githubClient, err := adapters.NewGithubClient(adapters.DefaultGitHubClientConfig())
@@ -167,21 +275,27 @@ type RegistryAdapterConfig struct {
 //	}
 //	client, err := packageregistry.NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_GITHUB_ACTIONS, &packageregistry.RegisterAdapterConfig{GitHubClient: githubClient})
 func NewRegistryAdapter(ecosystem packagev1.Ecosystem, config *RegistryAdapterConfig) (Client, error) {
+	if config == nil {
+		config = &RegistryAdapterConfig{}
+	}
+
 	switch ecosystem {
 	case packagev1.Ecosystem_ECOSYSTEM_NPM:
-		return NewNpmAdapter()
+		return newNpmAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_PYPI:
-		return NewPypiAdapter()
+		return newPypiAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS:
-		return NewRubyAdapter()
+		return newRubyAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_GO:
-		return NewGoAdapter()
+		return newGoAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_MAVEN:
-		return NewMavenAdapter()
+		return newMavenAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_CARGO:
-		return NewCratesAdapter()
+		return newCratesAdapter(config)
+	case packagev1.Ecosystem_ECOSYSTEM_NUGET:
+		return newNugetAdapter(config)
 	case packagev1.Ecosystem_ECOSYSTEM_GITHUB_ACTIONS, packagev1.Ecosystem_ECOSYSTEM_GITHUB_REPOSITORY:
-		if config == nil || config.GitHubClient == nil {
+		if config.GitHubClient == nil {
 			return nil, fmt.Errorf("github client is required for github ecosystems")
 		}
 
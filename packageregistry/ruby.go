@@ -1,41 +1,67 @@
 package packageregistry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	packagev1 "buf.build/gen/go/safedep/api/protocolbuffers/go/safedep/messages/package/v1"
 )
 
-type rubyAdapter struct{}
+type rubyAdapter struct {
+	registry registryEndpoint
+}
 
 // Verify that rubyAdapter implements the Client interface
 var _ Client = (*rubyAdapter)(nil)
 
-type rubyPublisherDiscovery struct{}
-type rubyPackageDiscovery struct{}
+type rubyPublisherDiscovery struct {
+	registry registryEndpoint
+}
+
+type rubyPackageDiscovery struct {
+	registry registryEndpoint
+}
+
+var (
+	_ ContextPublisherDiscovery = (*rubyPublisherDiscovery)(nil)
+	_ ContextPackageDiscovery   = (*rubyPackageDiscovery)(nil)
+)
 
 var _ Client = (*rubyAdapter)(nil)
 
 func NewRubyAdapter() (Client, error) {
-	return &rubyAdapter{}, nil
+	return newRubyAdapter(&RegistryAdapterConfig{})
+}
+
+func newRubyAdapter(config *RegistryAdapterConfig) (Client, error) {
+	registry, err := newRegistryEndpoint(config, config.RubyGems, rubyDefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rubyAdapter{registry: registry}, nil
 }
 
 func (na *rubyAdapter) PackageDiscovery() (PackageDiscovery, error) {
-	return &rubyPackageDiscovery{}, nil
+	return &rubyPackageDiscovery{registry: na.registry}, nil
 }
 
 func (na *rubyAdapter) PublisherDiscovery() (PublisherDiscovery, error) {
-	return &rubyPublisherDiscovery{}, nil
+	return &rubyPublisherDiscovery{registry: na.registry}, nil
 }
 
 func (np *rubyPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
+	return np.GetPackagePublisherContext(context.Background(), packageVersion)
+}
+
+func (np *rubyPublisherDiscovery) GetPackagePublisherContext(ctx context.Context, packageVersion *packagev1.PackageVersion) (*PackagePublisherInfo, error) {
 	packageName := packageVersion.GetPackage().GetName()
 
-	packageURL := rubyAPIEndpointGetPublishersForPackageURL(packageName)
-	res, err := httpClient().Get(packageURL)
+	packageURL := rubyAPIEndpointGetPublishersForPackageURL(np.registry.baseURL, packageName)
+	res, err := np.registry.get(ctx, packageURL)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 
 	if res.StatusCode == 404 {
@@ -70,11 +96,15 @@ func (np *rubyPublisherDiscovery) GetPackagePublisher(packageVersion *packagev1.
 }
 
 func (np *rubyPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*Package, error) {
-	publisherURL := rubyAPIEndpointPackageByAuthorURL(publisher.Name)
+	return np.GetPublisherPackagesContext(context.Background(), publisher)
+}
+
+func (np *rubyPublisherDiscovery) GetPublisherPackagesContext(ctx context.Context, publisher Publisher) ([]*Package, error) {
+	publisherURL := rubyAPIEndpointPackageByAuthorURL(np.registry.baseURL, publisher.Name)
 
-	res, err := httpClient().Get(publisherURL)
+	res, err := np.registry.get(ctx, publisherURL)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 
 	if res.StatusCode == 404 {
@@ -96,7 +126,7 @@ func (np *rubyPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*
 	packages := make([]*Package, 0, len(gemObjects))
 
 	for _, gemObject := range gemObjects {
-		pkg, err := convertGemObjectToPackage(gemObject)
+		pkg, err := convertGemObjectToPackage(ctx, np.registry, gemObject)
 		if err != nil {
 			return nil, err
 		}
@@ -109,17 +139,54 @@ func (np *rubyPublisherDiscovery) GetPublisherPackages(publisher Publisher) ([]*
 
 func (np *rubyPackageDiscovery) GetPackageDependencies(packageName string,
 	packageVersion string) (*PackageDependencyList, error) {
-	return nil, fmt.Errorf("dependency resolution is not supported for Ruby adapter")
+	return np.GetPackageDependenciesContext(context.Background(), packageName, packageVersion)
 }
 
-func (np *rubyPackageDiscovery) GetPackage(packageName string) (*Package, error) {
-	packageURL := rubyAPIEndpointPackageURL(packageName)
+// GetPackageDependenciesContext returns the runtime and development
+// dependencies of a gem version with their gem requirements
+func (np *rubyPackageDiscovery) GetPackageDependenciesContext(ctx context.Context, packageName string,
+	packageVersion string) (*PackageDependencyList, error) {
+	packageURL := rubyAPIEndpointPackageWithVersionURL(np.registry.baseURL, packageName, packageVersion)
 
-	res, err := httpClient().Get(packageURL)
+	res, err := np.registry.get(ctx, packageURL)
 	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, ErrPackageNotFound
+	}
+
+	if res.StatusCode != 200 {
 		return nil, ErrFailedToFetchPackage
 	}
 
+	var gemVersion rubyGemVersion
+	err = json.NewDecoder(res.Body).Decode(&gemVersion)
+	if err != nil {
+		return nil, ErrFailedToParsePackage
+	}
+
+	return &PackageDependencyList{
+		Dependencies:    convertGemDependencies(gemVersion.Dependencies.Runtime),
+		DevDependencies: convertGemDependencies(gemVersion.Dependencies.Development),
+	}, nil
+}
+
+func (np *rubyPackageDiscovery) GetPackage(packageName string) (*Package, error) {
+	return np.GetPackageContext(context.Background(), packageName)
+}
+
+func (np *rubyPackageDiscovery) GetPackageContext(ctx context.Context, packageName string) (*Package, error) {
+	packageURL := rubyAPIEndpointPackageURL(np.registry.baseURL, packageName)
+
+	res, err := np.registry.get(ctx, packageURL)
+	if err != nil {
+		return nil, err
+	}
+
 	if res.StatusCode == 404 {
 		return nil, ErrPackageNotFound
 	}
@@ -136,15 +203,31 @@ func (np *rubyPackageDiscovery) GetPackage(packageName string) (*Package, error)
 		return nil, ErrFailedToParsePackage
 	}
 
-	return convertGemObjectToPackage(gemObject)
+	return convertGemObjectToPackage(ctx, np.registry, gemObject)
 }
 
 func (np *rubyPackageDiscovery) GetPackageDownloadStats(packageName string) (DownloadStats, error) {
+	return np.GetPackageDownloadStatsContext(context.Background(), packageName)
+}
+
+func (np *rubyPackageDiscovery) GetPackageDownloadStatsContext(_ context.Context, packageName string) (DownloadStats, error) {
 	return DownloadStats{}, fmt.Errorf("download stats are not supported for Ruby adapter")
 }
 
-func convertGemObjectToPackage(gemObject gemObject) (*Package, error) {
-	pkgVersions, err := getPackageVersions(gemObject.Name)
+func convertGemDependencies(gemDependencies []rubyGemDependency) []PackageDependencyInfo {
+	dependencies := make([]PackageDependencyInfo, 0, len(gemDependencies))
+	for _, dependency := range gemDependencies {
+		dependencies = append(dependencies, PackageDependencyInfo{
+			Name:        dependency.Name,
+			VersionSpec: dependency.Requirements,
+		})
+	}
+
+	return dependencies
+}
+
+func convertGemObjectToPackage(ctx context.Context, registry registryEndpoint, gemObject gemObject) (*Package, error) {
+	pkgVersions, err := getPackageVersions(ctx, registry, gemObject.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -173,12 +256,12 @@ func convertGemObjectToPackage(gemObject gemObject) (*Package, error) {
 	return pkg, nil
 }
 
-func getPackageVersions(packageName string) ([]PackageVersionInfo, error) {
-	packageURL := rubyAPIEndpointAllVersionsURL(packageName)
+func getPackageVersions(ctx context.Context, registry registryEndpoint, packageName string) ([]PackageVersionInfo, error) {
+	packageURL := rubyAPIEndpointAllVersionsURL(registry.baseURL, packageName)
 
-	res, err := httpClient().Get(packageURL)
+	res, err := registry.get(ctx, packageURL)
 	if err != nil {
-		return nil, ErrFailedToFetchPackage
+		return nil, err
 	}
 
 	defer res.Body.Close()
@@ -21,6 +21,26 @@ type rubyPublisherData struct {
 	Email  string `json:"email"`
 }
 
+// rubyGemVersion is the metadata of a gem version
+// API (sample): https://rubygems.org/api/v2/rubygems/rails/versions/7.1.0.json
+type rubyGemVersion struct {
+	Name         string                 `json:"name"`
+	Version      string                 `json:"version"`
+	Dependencies rubyGemDependencyLists `json:"dependencies"`
+}
+
+type rubyGemDependencyLists struct {
+	Development []rubyGemDependency `json:"development"`
+	Runtime     []rubyGemDependency `json:"runtime"`
+}
+
+type rubyGemDependency struct {
+	Name string `json:"name"`
+
+	// Gem requirement such as "~> 7.1, >= 7.1.2"
+	Requirements string `json:"requirements"`
+}
+
 // ruby version data
 // API (sample): https://rubygems.org/api/v1/versions/rails.json
 // We only need version numbers, so we extract this only
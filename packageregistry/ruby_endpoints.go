@@ -5,22 +5,29 @@ import "fmt"
 // RUBY GEM API ENDPOINTS
 // DOCS: https://guides.rubygems.org/rubygems-org-api-v2/
 
+const rubyDefaultBaseURL = "https://rubygems.org"
+
 // We use v1 endpoint for this, as v2 endpoint requires version
 // We can find all the version of the package rubyAPIEndpointAllVersionURL, then we can use v2 endpoint to get the package metadata, but result is same
-func rubyAPIEndpointPackageURL(packageName string) string {
-	return fmt.Sprintf("https://rubygems.org/api/v1/gems/%s.json", packageName)
+func rubyAPIEndpointPackageURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/api/v1/gems/%s.json", baseURL, packageName)
+}
+
+// V2 API returns the metadata of a specific version including dependencies
+func rubyAPIEndpointPackageWithVersionURL(baseURL, packageName, version string) string {
+	return fmt.Sprintf("%s/api/v2/rubygems/%s/versions/%s.json", baseURL, packageName, version)
 }
 
-func rubyAPIEndpointGetPublishersForPackageURL(packageName string) string {
-	return fmt.Sprintf("https://rubygems.org/api/v1/gems/%s/owners.json", packageName)
+func rubyAPIEndpointGetPublishersForPackageURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/api/v1/gems/%s/owners.json", baseURL, packageName)
 }
 
 // Get all versions of a package
 // V1 API, v2 does not support this
-func rubyAPIEndpointAllVersionsURL(packageName string) string {
-	return fmt.Sprintf("https://rubygems.org/api/v1/versions/%s.json", packageName)
+func rubyAPIEndpointAllVersionsURL(baseURL, packageName string) string {
+	return fmt.Sprintf("%s/api/v1/versions/%s.json", baseURL, packageName)
 }
 
-func rubyAPIEndpointPackageByAuthorURL(author string) string {
-	return fmt.Sprintf("https://rubygems.org/api/v1/owners/%s/gems.json", author)
+func rubyAPIEndpointPackageByAuthorURL(baseURL, author string) string {
+	return fmt.Sprintf("%s/api/v1/owners/%s/gems.json", baseURL, author)
 }
@@ -2,6 +2,8 @@ package packageregistry
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/safedep/dry/semver"
@@ -243,3 +245,81 @@ func TestRubyGetPackageLatestVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestRubyGetPackageDependencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/rubygems/http-cookie/versions/1.0.8.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+			"name": "http-cookie",
+			"version": "1.0.8",
+			"dependencies": {
+				"development": [
+					{"name": "bundler", "requirements": ">= 1.2.0"},
+					{"name": "test-unit", "requirements": "~> 3.0, >= 3.2.1"}
+				],
+				"runtime": [
+					{"name": "domain_name", "requirements": "~> 0.5"}
+				]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name       string
+		pkgName    string
+		pkgVersion string
+
+		expectedError        error
+		expectedDependencies *PackageDependencyList
+	}{
+		{
+			name:       "runtime and development dependencies",
+			pkgName:    "http-cookie",
+			pkgVersion: "1.0.8",
+			expectedDependencies: &PackageDependencyList{
+				Dependencies: []PackageDependencyInfo{
+					{Name: "domain_name", VersionSpec: "~> 0.5"},
+				},
+				DevDependencies: []PackageDependencyInfo{
+					{Name: "bundler", VersionSpec: ">= 1.2.0"},
+					{Name: "test-unit", VersionSpec: "~> 3.0, >= 3.2.1"},
+				},
+			},
+		},
+		{
+			name:          "Incorrect package version",
+			pkgName:       "http-cookie",
+			pkgVersion:    "0.0.0",
+			expectedError: ErrPackageNotFound,
+		},
+	}
+
+	adapter, err := NewRegistryAdapter(packagev1.Ecosystem_ECOSYSTEM_RUBYGEMS, &RegistryAdapterConfig{
+		RubyGems: RegistryEndpoint{BaseURL: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("failed to create package registry ruby adapter: %v", err)
+	}
+
+	pd, err := adapter.PackageDiscovery()
+	if err != nil {
+		t.Fatalf("failed to create package discovery client in ruby adapter")
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			dependencies, err := pd.GetPackageDependencies(test.pkgName, test.pkgVersion)
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedDependencies, dependencies)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	backoffDefaultMaxAttempts     = 5
+	backoffDefaultInitialInterval = 100 * time.Millisecond
+	backoffDefaultMaxInterval     = 10 * time.Second
+	backoffDefaultMultiplier      = 2.0
+)
+
+var (
+	errInvalidBackoffInterval   = errors.New("invalid backoff interval")
+	errInvalidBackoffMultiplier = errors.New("backoff multiplier must be >= 1")
+)
+
+// Classifier decides whether an error returned by a retriable function
+// should be retried
+type Classifier func(err error) bool
+
+// BackoffFunc is a retriable function that is bound to a context
+type BackoffFunc func(ctx context.Context, arg RetryFuncArg) error
+
+// BackoffValueFunc is a retriable function returning a value
+type BackoffValueFunc[T any] func(ctx context.Context, arg RetryFuncArg) (T, error)
+
+type backoffConfig struct {
+	maxAttempts     int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	multiplier      float64
+	maxElapsedTime  time.Duration
+	classifier      Classifier
+	budget          RetryBudget
+	breaker         CircuitBreaker
+}
+
+type BackoffOption func(*backoffConfig)
+
+// WithMaxAttempts sets the maximum number of attempts including the first.
+// Zero means no limit, in which case a max elapsed time or a context
+// deadline should be set. Defaults to 5
+func WithMaxAttempts(n int) BackoffOption {
+	return func(c *backoffConfig) {
+		c.maxAttempts = n
+	}
+}
+
+// WithInitialInterval sets the upper bound of the first backoff.
+// Defaults to 100ms
+func WithInitialInterval(d time.Duration) BackoffOption {
+	return func(c *backoffConfig) {
+		c.initialInterval = d
+	}
+}
+
+// WithMaxInterval caps the upper bound of any backoff. Defaults to 10s
+func WithMaxInterval(d time.Duration) BackoffOption {
+	return func(c *backoffConfig) {
+		c.maxInterval = d
+	}
+}
+
+// WithMultiplier sets the factor by which the backoff upper bound grows
+// after each attempt. Defaults to 2
+func WithMultiplier(m float64) BackoffOption {
+	return func(c *backoffConfig) {
+		c.multiplier = m
+	}
+}
+
+// WithMaxElapsedTime stops retrying once the time since the first attempt
+// exceeds d. Zero means no limit
+func WithMaxElapsedTime(d time.Duration) BackoffOption {
+	return func(c *backoffConfig) {
+		c.maxElapsedTime = d
+	}
+}
+
+// WithClassifier overrides the classifier used to decide whether an error
+// is retried. Defaults to IsRetryable
+func WithClassifier(classifier Classifier) BackoffOption {
+	return func(c *backoffConfig) {
+		c.classifier = classifier
+	}
+}
+
+// WithRetryBudget records the outcome of every attempt in budget and stops
+// retrying when the budget denies a retry
+func WithRetryBudget(budget RetryBudget) BackoffOption {
+	return func(c *backoffConfig) {
+		c.budget = budget
+	}
+}
+
+// WithCircuitBreaker executes every attempt through breaker. Attempts
+// rejected by an open breaker are not retried
+func WithCircuitBreaker(breaker CircuitBreaker) BackoffOption {
+	return func(c *backoffConfig) {
+		c.breaker = breaker
+	}
+}
+
+// InvokeWithBackoff calls f until it succeeds, returns an error rejected
+// by the classifier, attempts or elapsed time are exhausted or ctx is done.
+// Sleeps between attempts use exponential backoff with full jitter. The
+// last error returned by f is returned, joined with the context error when
+// ctx is done while waiting
+func InvokeWithBackoff(ctx context.Context, f BackoffFunc, opts ...BackoffOption) error {
+	_, err := InvokeWithBackoffValue(ctx, func(ctx context.Context, arg RetryFuncArg) (struct{}, error) {
+		return struct{}{}, f(ctx, arg)
+	}, opts...)
+
+	return err
+}
+
+// InvokeWithBackoffValue is InvokeWithBackoff for functions returning a value
+func InvokeWithBackoffValue[T any](ctx context.Context, f BackoffValueFunc[T], opts ...BackoffOption) (T, error) {
+	var empty T
+
+	config := backoffConfig{
+		maxAttempts:     backoffDefaultMaxAttempts,
+		initialInterval: backoffDefaultInitialInterval,
+		maxInterval:     backoffDefaultMaxInterval,
+		multiplier:      backoffDefaultMultiplier,
+		classifier:      IsRetryable,
+	}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.maxAttempts < 0 {
+		return empty, errInvalidRetryCount
+	}
+
+	if config.initialInterval <= 0 || config.maxInterval < config.initialInterval {
+		return empty, errInvalidBackoffInterval
+	}
+
+	if config.multiplier < 1 {
+		return empty, errInvalidBackoffMultiplier
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return empty, err
+		}
+
+		res, err := invokeAttempt(ctx, &config, f, RetryFuncArg{Total: config.maxAttempts, Current: attempt})
+		if err == nil {
+			if config.budget != nil {
+				config.budget.OnSuccess()
+			}
+
+			return res, nil
+		}
+
+		if !config.classifier(err) {
+			return empty, err
+		}
+
+		if config.budget != nil {
+			config.budget.OnFailure()
+		}
+
+		if config.maxAttempts > 0 && attempt >= config.maxAttempts {
+			return empty, err
+		}
+
+		sleep := config.backoff(attempt)
+		if config.maxElapsedTime > 0 && time.Since(start)+sleep > config.maxElapsedTime {
+			return empty, err
+		}
+
+		if config.budget != nil && !config.budget.AllowRetry() {
+			return empty, errors.Join(err, ErrRetryBudgetExhausted)
+		}
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return empty, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func invokeAttempt[T any](ctx context.Context, config *backoffConfig, f BackoffValueFunc[T], arg RetryFuncArg) (T, error) {
+	if config.breaker == nil {
+		return f(ctx, arg)
+	}
+
+	return ExecuteWithBreaker(ctx, config.breaker, func(ctx context.Context) (T, error) {
+		return f(ctx, arg)
+	})
+}
+
+// backoff returns a random duration in [0, bound) where bound grows
+// exponentially with attempt and is capped by the max interval
+func (c *backoffConfig) backoff(attempt int) time.Duration {
+	bound := float64(c.initialInterval) * math.Pow(c.multiplier, float64(attempt-1))
+	if bound > float64(c.maxInterval) {
+		bound = float64(c.maxInterval)
+	}
+
+	return time.Duration(rand.Int64N(int64(bound) + 1))
+}
+
+// IsRetryable is the default classifier. Errors that declare themselves as
+// retryable through an IsRetryable() or Retriable() method, such as
+// aiservices.ModelError and errors from the dry errors package, are retried
+// accordingly. gRPC status errors are retried only for codes.Unavailable.
+// Context errors and circuit breaker rejections are never retried. Any
+// other error is retried
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrCircuitHalfOpenLimit) {
+		return false
+	}
+
+	var modelErr interface{ IsRetryable() bool }
+	if errors.As(err, &modelErr) {
+		return modelErr.IsRetryable()
+	}
+
+	var apiErr interface{ Retriable() bool }
+	if errors.As(err, &apiErr) {
+		return apiErr.Retriable()
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st.Code() == codes.Unavailable
+	}
+
+	return true
+}
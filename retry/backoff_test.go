@@ -0,0 +1,219 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/safedep/dry/aiservices"
+	"github.com/safedep/dry/api"
+	dryerrors "github.com/safedep/dry/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errTestTransient = errors.New("transient")
+
+func TestInvokeWithBackoff(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     []BackoffOption
+		failures int
+		fail     error
+		attempts int
+		err      error
+	}{
+		{
+			"succeeds immediately",
+			nil,
+			0,
+			nil,
+			1,
+			nil,
+		},
+		{
+			"succeeds after transient failures",
+			[]BackoffOption{WithInitialInterval(time.Millisecond)},
+			3,
+			errTestTransient,
+			4,
+			nil,
+		},
+		{
+			"stops after max attempts",
+			[]BackoffOption{WithMaxAttempts(3), WithInitialInterval(time.Millisecond)},
+			10,
+			errTestTransient,
+			3,
+			errTestTransient,
+		},
+		{
+			"does not retry errors rejected by classifier",
+			[]BackoffOption{WithClassifier(func(error) bool { return false })},
+			10,
+			errTestTransient,
+			1,
+			errTestTransient,
+		},
+		{
+			"fails with invalid interval",
+			[]BackoffOption{WithInitialInterval(0)},
+			0,
+			nil,
+			0,
+			errInvalidBackoffInterval,
+		},
+		{
+			"fails with max interval below initial interval",
+			[]BackoffOption{WithInitialInterval(time.Second), WithMaxInterval(time.Millisecond)},
+			0,
+			nil,
+			0,
+			errInvalidBackoffInterval,
+		},
+		{
+			"fails with invalid multiplier",
+			[]BackoffOption{WithMultiplier(0.5)},
+			0,
+			nil,
+			0,
+			errInvalidBackoffMultiplier,
+		},
+		{
+			"fails with negative attempts",
+			[]BackoffOption{WithMaxAttempts(-1)},
+			0,
+			nil,
+			0,
+			errInvalidRetryCount,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			err := InvokeWithBackoff(context.Background(), func(_ context.Context, arg RetryFuncArg) error {
+				attempts++
+				assert.Equal(t, attempts, arg.Current)
+
+				if attempts <= test.failures {
+					return test.fail
+				}
+
+				return nil
+			}, test.opts...)
+
+			assert.Equal(t, test.attempts, attempts)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInvokeWithBackoffContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := InvokeWithBackoff(ctx, func(_ context.Context, _ RetryFuncArg) error {
+		attempts++
+		cancel()
+
+		return errTestTransient
+	}, WithInitialInterval(time.Hour), WithMaxInterval(time.Hour))
+
+	assert.Equal(t, 1, attempts)
+	assert.ErrorIs(t, err, errTestTransient)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = InvokeWithBackoff(ctx, func(_ context.Context, _ RetryFuncArg) error {
+		attempts++
+		return nil
+	})
+
+	assert.Equal(t, 1, attempts)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestInvokeWithBackoffMaxElapsedTime(t *testing.T) {
+	attempts := 0
+	err := InvokeWithBackoff(context.Background(), func(_ context.Context, _ RetryFuncArg) error {
+		attempts++
+		time.Sleep(5 * time.Millisecond)
+
+		return errTestTransient
+	}, WithMaxAttempts(0), WithInitialInterval(time.Millisecond), WithMaxElapsedTime(time.Millisecond))
+
+	assert.Equal(t, 1, attempts)
+	assert.ErrorIs(t, err, errTestTransient)
+}
+
+func TestInvokeWithBackoffValue(t *testing.T) {
+	res, err := InvokeWithBackoffValue(context.Background(), func(_ context.Context, arg RetryFuncArg) (string, error) {
+		if arg.Current < 2 {
+			return "", errTestTransient
+		}
+
+		return fmt.Sprintf("attempt %d", arg.Current), nil
+	}, WithInitialInterval(time.Millisecond))
+
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 2", res)
+
+	res, err = InvokeWithBackoffValue(context.Background(), func(_ context.Context, _ RetryFuncArg) (string, error) {
+		return "partial", errTestTransient
+	}, WithMaxAttempts(1))
+
+	assert.ErrorIs(t, err, errTestTransient)
+	assert.Empty(t, res)
+}
+
+func TestBackoffIsBoundedAndGrows(t *testing.T) {
+	c := backoffConfig{
+		initialInterval: 10 * time.Millisecond,
+		maxInterval:     50 * time.Millisecond,
+		multiplier:      2,
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, c.backoff(1), 10*time.Millisecond)
+		assert.LessOrEqual(t, c.backoff(2), 20*time.Millisecond)
+		assert.LessOrEqual(t, c.backoff(100), 50*time.Millisecond)
+		assert.GreaterOrEqual(t, c.backoff(100), time.Duration(0))
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errTestTransient, true},
+		{"context canceled", fmt.Errorf("wrapped: %w", context.Canceled), false},
+		{"context deadline", context.DeadlineExceeded, false},
+		{"retryable model error", &aiservices.ModelError{Retryable: true}, true},
+		{"non retryable model error", fmt.Errorf("wrapped: %w", &aiservices.ModelError{}), false},
+		{
+			"api error",
+			dryerrors.BuildApiError(api.ApiErrorTypeInternalError, api.ApiErrorCodeAppGenericError, "failed"),
+			false,
+		},
+		{"grpc unavailable", status.Error(codes.Unavailable, "down"), true},
+		{"wrapped grpc unavailable", fmt.Errorf("wrapped: %w", status.Error(codes.Unavailable, "down")), true},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "bad"), false},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.retryable, IsRetryable(test.err))
+		})
+	}
+}
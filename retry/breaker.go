@@ -0,0 +1,190 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/safedep/dry/log"
+	gobreaker "github.com/sony/gobreaker/v2"
+)
+
+const (
+	breakerDefaultName                = "default"
+	breakerDefaultMaxHalfOpenRequests = 1
+	breakerDefaultOpenTimeout         = time.Minute
+	breakerDefaultConsecutiveFailures = 5
+)
+
+var (
+	// ErrCircuitOpen is returned without invoking the function when the
+	// circuit breaker is open
+	ErrCircuitOpen = gobreaker.ErrOpenState
+
+	// ErrCircuitHalfOpenLimit is returned without invoking the function when
+	// the circuit breaker is half open and already probing the upstream
+	ErrCircuitHalfOpenLimit = gobreaker.ErrTooManyRequests
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	CircuitStateClosed CircuitState = iota
+	CircuitStateHalfOpen
+	CircuitStateOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitStateClosed:
+		return "closed"
+	case CircuitStateHalfOpen:
+		return "half-open"
+	case CircuitStateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures a circuit breaker. Zero values are
+// replaced with defaults
+type CircuitBreakerConfig struct {
+	// Name identifies the breaker in logs and metrics. Defaults to "default"
+	Name string
+
+	// Number of consecutive failures that trips the breaker. Defaults to 5
+	ConsecutiveFailures uint32
+
+	// Duration for which the breaker stays open before allowing probe
+	// requests through. Defaults to 1 minute
+	OpenTimeout time.Duration
+
+	// Number of probe requests allowed while half open. Defaults to 1
+	MaxHalfOpenRequests uint32
+
+	// Period after which failure counts are cleared while closed. Zero
+	// means counts are cleared only on a success
+	Interval time.Duration
+
+	// Decides whether an error counts as a failure. Defaults to treating
+	// every error as a failure. Errors for which it returns false count as
+	// a success. Context cancellation is never counted
+	IsFailure Classifier
+
+	// Called when the breaker changes state
+	OnStateChange func(name string, from, to CircuitState)
+}
+
+// CircuitBreaker stops calling a failing upstream until it recovers
+type CircuitBreaker interface {
+	// Execute invokes f unless the breaker is open. Returns ErrCircuitOpen
+	// or ErrCircuitHalfOpenLimit when f is not invoked
+	Execute(ctx context.Context, f func(ctx context.Context) error) error
+
+	// State returns the current state of the breaker
+	State() CircuitState
+}
+
+type circuitBreaker struct {
+	name    string
+	breaker *gobreaker.CircuitBreaker[struct{}]
+}
+
+// NewCircuitBreaker creates a circuit breaker that can be shared by all
+// callers of the same upstream
+func NewCircuitBreaker(config CircuitBreakerConfig) CircuitBreaker {
+	if config.Name == "" {
+		config.Name = breakerDefaultName
+	}
+
+	if config.ConsecutiveFailures == 0 {
+		config.ConsecutiveFailures = breakerDefaultConsecutiveFailures
+	}
+
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = breakerDefaultOpenTimeout
+	}
+
+	if config.MaxHalfOpenRequests == 0 {
+		config.MaxHalfOpenRequests = breakerDefaultMaxHalfOpenRequests
+	}
+
+	if config.IsFailure == nil {
+		config.IsFailure = isBreakerFailure
+	}
+
+	return &circuitBreaker{
+		name: config.Name,
+		breaker: gobreaker.NewCircuitBreaker[struct{}](gobreaker.Settings{
+			Name:        config.Name,
+			MaxRequests: config.MaxHalfOpenRequests,
+			Interval:    config.Interval,
+			Timeout:     config.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= config.ConsecutiveFailures
+			},
+			IsSuccessful: func(err error) bool {
+				return err == nil || !config.IsFailure(err)
+			},
+			IsExcluded: func(err error) bool {
+				return errors.Is(err, context.Canceled)
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				log.Infof("Circuit breaker %s: %s -> %s", name, from, to)
+
+				observeBreakerStateChange(name, circuitState(from), circuitState(to))
+				if config.OnStateChange != nil {
+					config.OnStateChange(name, circuitState(from), circuitState(to))
+				}
+			},
+		}),
+	}
+}
+
+func (cb *circuitBreaker) Execute(ctx context.Context, f func(ctx context.Context) error) error {
+	_, err := cb.breaker.Execute(func() (struct{}, error) {
+		return struct{}{}, f(ctx)
+	})
+
+	observeBreakerRequest(cb.name, err)
+	return err
+}
+
+func (cb *circuitBreaker) State() CircuitState {
+	return circuitState(cb.breaker.State())
+}
+
+// ExecuteWithBreaker is CircuitBreaker.Execute for functions returning a value
+func ExecuteWithBreaker[T any](ctx context.Context, cb CircuitBreaker, f func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	err := cb.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = f(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+
+	return res, nil
+}
+
+func isBreakerFailure(_ error) bool {
+	return true
+}
+
+func circuitState(s gobreaker.State) CircuitState {
+	switch s {
+	case gobreaker.StateHalfOpen:
+		return CircuitStateHalfOpen
+	case gobreaker.StateOpen:
+		return CircuitStateOpen
+	default:
+		return CircuitStateClosed
+	}
+}
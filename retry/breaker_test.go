@@ -0,0 +1,150 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerTripsAndRecovers(t *testing.T) {
+	var transitions []string
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Name:                "test",
+		ConsecutiveFailures: 2,
+		OpenTimeout:         20 * time.Millisecond,
+		OnStateChange: func(name string, from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+
+	failing := func(context.Context) error { return errTestTransient }
+	calls := 0
+	counting := func(context.Context) error {
+		calls++
+		return nil
+	}
+
+	assert.ErrorIs(t, cb.Execute(context.Background(), failing), errTestTransient)
+	assert.Equal(t, CircuitStateClosed, cb.State())
+
+	assert.ErrorIs(t, cb.Execute(context.Background(), failing), errTestTransient)
+	assert.Equal(t, CircuitStateOpen, cb.State())
+
+	assert.ErrorIs(t, cb.Execute(context.Background(), counting), ErrCircuitOpen)
+	assert.Equal(t, 0, calls)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, CircuitStateHalfOpen, cb.State())
+
+	assert.NoError(t, cb.Execute(context.Background(), counting))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, CircuitStateClosed, cb.State())
+
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, transitions)
+}
+
+func TestCircuitBreakerIgnoresNonFailures(t *testing.T) {
+	errNotFound := errors.New("not found")
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		ConsecutiveFailures: 1,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errNotFound)
+		},
+	})
+
+	assert.ErrorIs(t, cb.Execute(context.Background(), func(context.Context) error {
+		return errNotFound
+	}), errNotFound)
+	assert.Equal(t, CircuitStateClosed, cb.State())
+
+	assert.ErrorIs(t, cb.Execute(context.Background(), func(context.Context) error {
+		return context.Canceled
+	}), context.Canceled)
+	assert.Equal(t, CircuitStateClosed, cb.State())
+}
+
+func TestExecuteWithBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 1})
+
+	res, err := ExecuteWithBreaker(context.Background(), cb, func(context.Context) (int, error) {
+		return 42, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, res)
+
+	res, err = ExecuteWithBreaker(context.Background(), cb, func(context.Context) (int, error) {
+		return 1, errTestTransient
+	})
+
+	assert.ErrorIs(t, err, errTestTransient)
+	assert.Equal(t, 0, res)
+
+	_, err = ExecuteWithBreaker(context.Background(), cb, func(context.Context) (int, error) {
+		return 42, nil
+	})
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestInvokeWithBackoffCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 2, OpenTimeout: time.Hour})
+
+	attempts := 0
+	err := InvokeWithBackoff(context.Background(), func(context.Context, RetryFuncArg) error {
+		attempts++
+		return errTestTransient
+	}, WithCircuitBreaker(cb), WithMaxAttempts(5), WithInitialInterval(time.Millisecond))
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	var code atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(int(code.Load()))
+	}))
+
+	defer server.Close()
+
+	cb := NewCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 2, OpenTimeout: time.Hour})
+	client := &http.Client{Transport: NewCircuitBreakerTransport(nil, cb)}
+
+	cases := []struct {
+		name   string
+		status int
+		state  CircuitState
+		err    error
+	}{
+		{"success", http.StatusOK, CircuitStateClosed, nil},
+		{"client error is not a failure", http.StatusNotFound, CircuitStateClosed, nil},
+		{"server error", http.StatusServiceUnavailable, CircuitStateClosed, nil},
+		{"rate limited trips the breaker", http.StatusTooManyRequests, CircuitStateOpen, nil},
+		{"rejected while open", http.StatusOK, CircuitStateOpen, ErrCircuitOpen},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			code.Store(int32(test.status))
+
+			res, err := client.Get(server.URL)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.status, res.StatusCode)
+				assert.NoError(t, res.Body.Close())
+			}
+
+			assert.Equal(t, test.state, cb.State())
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	budgetDefaultName       = "default"
+	budgetDefaultMaxTokens  = 10
+	budgetDefaultTokenRatio = 0.1
+)
+
+var (
+	// ErrRetryBudgetExhausted is joined with the last error when a retry
+	// is denied by the retry budget
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+	errInvalidRetryBudget = errors.New("invalid retry budget")
+)
+
+// RetryBudgetConfig configures a retry budget. Zero values are replaced
+// with defaults
+type RetryBudgetConfig struct {
+	// Name identifies the budget in metrics. Defaults to "default"
+	Name string
+
+	// Maximum number of tokens in the budget. Defaults to 10
+	MaxTokens float64
+
+	// Tokens added to the budget by every successful call. Defaults to 0.1
+	TokenRatio float64
+}
+
+// RetryBudget limits retries against an upstream that is failing for
+// most callers so that retries do not amplify an outage. It follows the
+// gRPC retry throttling scheme where every failure removes a token, every
+// success adds TokenRatio tokens and retries are allowed only while more
+// than half of MaxTokens are available
+type RetryBudget interface {
+	// OnSuccess records a successful call
+	OnSuccess()
+
+	// OnFailure records a failed call
+	OnFailure()
+
+	// AllowRetry reports whether a failed call may be retried
+	AllowRetry() bool
+}
+
+type retryBudget struct {
+	m      sync.Mutex
+	config RetryBudgetConfig
+	tokens float64
+}
+
+// NewRetryBudget creates a retry budget that can be shared by all callers
+// of the same upstream
+func NewRetryBudget(config RetryBudgetConfig) (RetryBudget, error) {
+	if config.MaxTokens < 0 || config.TokenRatio < 0 {
+		return nil, errInvalidRetryBudget
+	}
+
+	if config.Name == "" {
+		config.Name = budgetDefaultName
+	}
+
+	if config.MaxTokens == 0 {
+		config.MaxTokens = budgetDefaultMaxTokens
+	}
+
+	if config.TokenRatio == 0 {
+		config.TokenRatio = budgetDefaultTokenRatio
+	}
+
+	return &retryBudget{
+		config: config,
+		tokens: config.MaxTokens,
+	}, nil
+}
+
+func (b *retryBudget) OnSuccess() {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.tokens = min(b.config.MaxTokens, b.tokens+b.config.TokenRatio)
+}
+
+func (b *retryBudget) OnFailure() {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.tokens = max(0, b.tokens-1)
+}
+
+func (b *retryBudget) AllowRetry() bool {
+	b.m.Lock()
+	allowed := b.tokens > b.config.MaxTokens/2
+	b.m.Unlock()
+
+	observeBudgetRetry(b.config.Name, allowed)
+	return allowed
+}
@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBudget(t *testing.T) {
+	_, err := NewRetryBudget(RetryBudgetConfig{MaxTokens: -1})
+	assert.ErrorIs(t, err, errInvalidRetryBudget)
+
+	b, err := NewRetryBudget(RetryBudgetConfig{MaxTokens: 4, TokenRatio: 0.5})
+	require.NoError(t, err)
+
+	assert.True(t, b.AllowRetry())
+
+	// Tokens drop to 2 which is not above half of the max
+	b.OnFailure()
+	b.OnFailure()
+	assert.False(t, b.AllowRetry())
+
+	b.OnSuccess()
+	assert.True(t, b.AllowRetry())
+
+	// Tokens never exceed the max or drop below zero
+	for i := 0; i < 10; i++ {
+		b.OnFailure()
+	}
+
+	b.OnSuccess()
+	b.OnSuccess()
+	b.OnSuccess()
+	b.OnSuccess()
+	b.OnSuccess()
+	assert.True(t, b.AllowRetry())
+}
+
+func TestInvokeWithBackoffRetryBudget(t *testing.T) {
+	b, err := NewRetryBudget(RetryBudgetConfig{MaxTokens: 4})
+	require.NoError(t, err)
+
+	attempts := 0
+	err = InvokeWithBackoff(context.Background(), func(context.Context, RetryFuncArg) error {
+		attempts++
+		return errTestTransient
+	}, WithRetryBudget(b), WithMaxAttempts(10), WithInitialInterval(time.Millisecond))
+
+	assert.ErrorIs(t, err, errTestTransient)
+	assert.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.Equal(t, 2, attempts)
+}
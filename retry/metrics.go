@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"errors"
+
+	"github.com/safedep/dry/obs"
+)
+
+const (
+	metricResultSuccess   = "success"
+	metricResultError     = "error"
+	metricResultRejected  = "rejected"
+	metricResultAllowed   = "allowed"
+	metricResultExhausted = "exhausted"
+)
+
+var (
+	metricBreakerRequestsTotal = obs.NewCounterVec(
+		"retry_circuit_breaker_requests_total",
+		"Total number of calls made through circuit breakers",
+		[]string{"name", "result"},
+	)
+
+	metricBreakerStateChangesTotal = obs.NewCounterVec(
+		"retry_circuit_breaker_state_changes_total",
+		"Total number of circuit breaker state transitions",
+		[]string{"name", "from", "to"},
+	)
+
+	metricBudgetRetriesTotal = obs.NewCounterVec(
+		"retry_budget_retries_total",
+		"Total number of retries checked against retry budgets",
+		[]string{"name", "result"},
+	)
+)
+
+func observeBreakerRequest(name string, err error) {
+	result := metricResultSuccess
+	switch {
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrCircuitHalfOpenLimit):
+		result = metricResultRejected
+	case err != nil:
+		result = metricResultError
+	}
+
+	metricBreakerRequestsTotal.WithLabels(map[string]string{
+		"name":   name,
+		"result": result,
+	}).Inc()
+}
+
+func observeBreakerStateChange(name string, from, to CircuitState) {
+	metricBreakerStateChangesTotal.WithLabels(map[string]string{
+		"name": name,
+		"from": from.String(),
+		"to":   to.String(),
+	}).Inc()
+}
+
+func observeBudgetRetry(name string, allowed bool) {
+	result := metricResultAllowed
+	if !allowed {
+		result = metricResultExhausted
+	}
+
+	metricBudgetRetriesTotal.WithLabels(map[string]string{
+		"name":   name,
+		"result": result,
+	}).Inc()
+}
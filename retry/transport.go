@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type breakerStatusError struct {
+	code int
+}
+
+func (e *breakerStatusError) Error() string {
+	return fmt.Sprintf("upstream responded with status %d", e.code)
+}
+
+type breakerTransport struct {
+	base    http.RoundTripper
+	breaker CircuitBreaker
+}
+
+// NewCircuitBreakerTransport wraps base so that requests are sent through
+// the circuit breaker. Transport errors, 5xx and 429 responses count as
+// failures. Responses are returned to the caller unchanged. A nil base
+// uses http.DefaultTransport
+func NewCircuitBreakerTransport(base http.RoundTripper, breaker CircuitBreaker) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &breakerTransport{base: base, breaker: breaker}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var res *http.Response
+	err := t.breaker.Execute(req.Context(), func(_ context.Context) error {
+		var err error
+		res, err = t.base.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError ||
+			res.StatusCode == http.StatusTooManyRequests {
+			return &breakerStatusError{code: res.StatusCode}
+		}
+
+		return nil
+	})
+
+	var statusErr *breakerStatusError
+	if errors.As(err, &statusErr) {
+		return res, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
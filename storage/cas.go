@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	casDefaultPrefix   = "cas/"
+	casDigestAlgorithm = "sha256"
+)
+
+var (
+	// ErrDigestMismatch is returned while reading a blob or a file whose
+	// content does not match its digest
+	ErrDigestMismatch = errors.New("storage: digest mismatch")
+
+	errInvalidDigest  = errors.New("storage: invalid digest")
+	errInvalidRefName = errors.New("storage: invalid ref name")
+)
+
+// Digest identifies content by its hash in the form sha256:<hex>
+type Digest string
+
+// ParseDigest validates s as a sha256 digest
+func ParseDigest(s string) (Digest, error) {
+	algorithm, encoded, ok := strings.Cut(s, ":")
+	if !ok || algorithm != casDigestAlgorithm || len(encoded) != sha256.Size*2 {
+		return "", fmt.Errorf("%w: %q", errInvalidDigest, s)
+	}
+
+	if _, err := hex.DecodeString(encoded); err != nil || strings.ToLower(encoded) != encoded {
+		return "", fmt.Errorf("%w: %q", errInvalidDigest, s)
+	}
+
+	return Digest(s), nil
+}
+
+// Hex returns the hex encoded hash of the digest
+func (d Digest) Hex() string {
+	_, encoded, _ := strings.Cut(string(d), ":")
+	return encoded
+}
+
+func (d Digest) String() string {
+	return string(d)
+}
+
+// ContentAddressableStorage stores blobs by the digest of their content so
+// that identical content is stored once irrespective of the names it is
+// referenced by
+type ContentAddressableStorage interface {
+	// PutBlob stores content and returns its digest. The content is not
+	// written again when a blob with the same digest exists
+	PutBlob(ctx context.Context, reader io.Reader) (Digest, error)
+
+	// GetBlob returns the content of a blob. The content is verified
+	// against the digest while reading and ErrDigestMismatch is returned
+	// instead of io.EOF when it does not match
+	GetBlob(ctx context.Context, digest Digest) (io.ReadCloser, error)
+
+	// Ref points name to a blob, replacing any existing reference
+	Ref(ctx context.Context, name string, digest Digest) error
+
+	// Resolve returns the digest referenced by name
+	Resolve(ctx context.Context, name string) (Digest, error)
+}
+
+type ContentAddressableStorageConfig struct {
+	// Prefix of keys used for blobs and refs in the underlying storage.
+	// Defaults to "cas/"
+	Prefix string
+
+	// Directory used to spool content while its digest is computed.
+	// Defaults to os.TempDir()
+	TempDir string
+}
+
+type contentAddressableStorage struct {
+	storage StorageWriter
+	config  ContentAddressableStorageConfig
+}
+
+// NewContentAddressableStorage creates a CAS on top of storage. Blobs are
+// stored under <prefix>blobs/sha256/<hex> and refs under <prefix>refs/<name>
+func NewContentAddressableStorage(storage StorageWriter,
+	config ContentAddressableStorageConfig) (ContentAddressableStorage, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("cas storage: storage is required")
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = casDefaultPrefix
+	}
+
+	return &contentAddressableStorage{storage: storage, config: config}, nil
+}
+
+// PutBlob spools content to a temporary file while hashing it since the
+// key is known only after the content is fully read
+func (c *contentAddressableStorage) PutBlob(ctx context.Context, reader io.Reader) (Digest, error) {
+	spool, err := os.CreateTemp(c.config.TempDir, "cas-blob-*")
+	if err != nil {
+		return "", fmt.Errorf("cas storage: failed to create spool file: %w", err)
+	}
+
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(spool, h), &contextReader{ctx: ctx, reader: reader}); err != nil {
+		return "", fmt.Errorf("cas storage: failed to spool content: %w", err)
+	}
+
+	digest := Digest(casDigestAlgorithm + ":" + hex.EncodeToString(h.Sum(nil)))
+
+	exists, err := c.blobExists(ctx, digest)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return digest, nil
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("cas storage: failed to rewind spool file: %w", err)
+	}
+
+	if err := putContext(ctx, c.storage, c.blobKey(digest), spool); err != nil {
+		return "", fmt.Errorf("cas storage: failed to store blob: %w", err)
+	}
+
+	return digest, nil
+}
+
+func (c *contentAddressableStorage) GetBlob(ctx context.Context, digest Digest) (io.ReadCloser, error) {
+	digest, err := ParseDigest(string(digest))
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := getContext(ctx, c.storage, c.blobKey(digest))
+	if err != nil {
+		return nil, fmt.Errorf("cas storage: failed to get blob: %w", err)
+	}
+
+	return &digestVerifyingReader{
+		reader: reader,
+		hash:   sha256.New(),
+		digest: digest,
+	}, nil
+}
+
+func (c *contentAddressableStorage) Ref(ctx context.Context, name string, digest Digest) error {
+	if err := validateRefName(name); err != nil {
+		return err
+	}
+
+	digest, err := ParseDigest(string(digest))
+	if err != nil {
+		return err
+	}
+
+	if err := putContext(ctx, c.storage, c.refKey(name), strings.NewReader(digest.String())); err != nil {
+		return fmt.Errorf("cas storage: failed to store ref: %w", err)
+	}
+
+	return nil
+}
+
+func (c *contentAddressableStorage) Resolve(ctx context.Context, name string) (Digest, error) {
+	if err := validateRefName(name); err != nil {
+		return "", err
+	}
+
+	reader, err := getContext(ctx, c.storage, c.refKey(name))
+	if err != nil {
+		return "", fmt.Errorf("cas storage: failed to get ref: %w", err)
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	// Refs are tiny, a larger object is not a ref written by us
+	content, err := io.ReadAll(io.LimitReader(reader, 256))
+	if err != nil {
+		return "", fmt.Errorf("cas storage: failed to read ref: %w", err)
+	}
+
+	return ParseDigest(strings.TrimSpace(string(content)))
+}
+
+// blobExists uses Exists when supported by the storage and falls back to
+// opening the blob, treating any failure to open it as missing
+func (c *contentAddressableStorage) blobExists(ctx context.Context, digest Digest) (bool, error) {
+	if objects, ok := c.storage.(ObjectStorage); ok {
+		exists, err := objects.Exists(ctx, c.blobKey(digest))
+		if err != nil {
+			return false, fmt.Errorf("cas storage: failed to check blob: %w", err)
+		}
+
+		return exists, nil
+	}
+
+	reader, err := getContext(ctx, c.storage, c.blobKey(digest))
+	if err != nil {
+		return false, nil
+	}
+
+	return true, reader.Close()
+}
+
+func (c *contentAddressableStorage) blobKey(digest Digest) string {
+	return fmt.Sprintf("%sblobs/%s/%s", c.config.Prefix, casDigestAlgorithm, digest.Hex())
+}
+
+func (c *contentAddressableStorage) refKey(name string) string {
+	return c.config.Prefix + "refs/" + strings.Trim(name, "/")
+}
+
+func validateRefName(name string) error {
+	name = strings.Trim(name, "/")
+	if name == "" || strings.Contains(name, "..") {
+		return fmt.Errorf("%w: %q", errInvalidRefName, name)
+	}
+
+	return nil
+}
+
+// digestVerifyingReader hashes content as it is read and fails at EOF when
+// the content does not match the digest
+type digestVerifyingReader struct {
+	reader io.ReadCloser
+	hash   hash.Hash
+	digest Digest
+}
+
+func (r *digestVerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+
+	if errors.Is(err, io.EOF) && hex.EncodeToString(r.hash.Sum(nil)) != r.digest.Hex() {
+		return n, fmt.Errorf("%w: expected %s", ErrDigestMismatch, r.digest)
+	}
+
+	return n, err
+}
+
+func (r *digestVerifyingReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainStorageWriter hides the optional capabilities of a driver
+type plainStorageWriter struct {
+	StorageWriter
+}
+
+func TestContentAddressableStorage(t *testing.T) {
+	cases := []struct {
+		name  string
+		plain bool
+	}{
+		{"object storage", false},
+		{"plain storage writer", true},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+			require.NoError(t, err)
+
+			var backend StorageWriter = driver
+			if test.plain {
+				backend = plainStorageWriter{driver}
+			}
+
+			cas, err := NewContentAddressableStorage(backend, ContentAddressableStorageConfig{TempDir: t.TempDir()})
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			sum := sha256.Sum256([]byte("package tarball"))
+			expected := Digest("sha256:" + hex.EncodeToString(sum[:]))
+
+			digest, err := cas.PutBlob(ctx, strings.NewReader("package tarball"))
+			require.NoError(t, err)
+			assert.Equal(t, expected, digest)
+
+			blobPath := filepath.Join(root, "cas", "blobs", "sha256", expected.Hex())
+			first, err := os.Stat(blobPath)
+			require.NoError(t, err)
+
+			// Identical content is not written again
+			digest, err = cas.PutBlob(ctx, strings.NewReader("package tarball"))
+			require.NoError(t, err)
+			assert.Equal(t, expected, digest)
+
+			second, err := os.Stat(blobPath)
+			require.NoError(t, err)
+			assert.Equal(t, first.ModTime(), second.ModTime())
+
+			reader, err := cas.GetBlob(ctx, digest)
+			require.NoError(t, err)
+
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, "package tarball", string(content))
+			assert.NoError(t, reader.Close())
+
+			require.NoError(t, cas.Ref(ctx, "npm/express/4.18.2.tgz", digest))
+			require.NoError(t, cas.Ref(ctx, "mirror/express.tgz", digest))
+
+			resolved, err := cas.Resolve(ctx, "npm/express/4.18.2.tgz")
+			require.NoError(t, err)
+			assert.Equal(t, digest, resolved)
+
+			_, err = cas.Resolve(ctx, "npm/missing")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestContentAddressableStorageDetectsCorruption(t *testing.T) {
+	root := t.TempDir()
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	cas, err := NewContentAddressableStorage(driver, ContentAddressableStorageConfig{Prefix: "blobs-"})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	digest, err := cas.PutBlob(ctx, strings.NewReader("model weights"))
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(root, "blobs-blobs", "sha256", digest.Hex()), []byte("tampered"), 0o644)
+	require.NoError(t, err)
+
+	reader, err := cas.GetBlob(ctx, digest)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+	assert.NoError(t, reader.Close())
+}
+
+func TestContentAddressableStorageValidation(t *testing.T) {
+	_, err := NewContentAddressableStorage(nil, ContentAddressableStorageConfig{})
+	assert.Error(t, err)
+
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir()})
+	require.NoError(t, err)
+
+	cas, err := NewContentAddressableStorage(driver, ContentAddressableStorageConfig{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	valid := Digest("sha256:" + strings.Repeat("a", 64))
+
+	cases := []struct {
+		name   string
+		digest Digest
+		ref    string
+		err    error
+	}{
+		{"unknown algorithm", Digest("md5:" + strings.Repeat("a", 32)), "name", errInvalidDigest},
+		{"short digest", Digest("sha256:abc"), "name", errInvalidDigest},
+		{"upper case digest", Digest("sha256:" + strings.Repeat("A", 64)), "name", errInvalidDigest},
+		{"empty ref", valid, "/", errInvalidRefName},
+		{"escaping ref", valid, "../name", errInvalidRefName},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.ErrorIs(t, cas.Ref(ctx, test.ref, test.digest), test.err)
+		})
+	}
+
+	_, err = cas.GetBlob(ctx, "sha256:abc")
+	assert.ErrorIs(t, err, errInvalidDigest)
+}
@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionAlgorithm is a compression applied to objects. The value is
+// the HTTP Content-Encoding of the algorithm
+type CompressionAlgorithm string
+
+const (
+	CompressionGzip CompressionAlgorithm = "gzip"
+	CompressionZstd CompressionAlgorithm = "zstd"
+)
+
+// Key suffixes of compressed objects when the encoding is recorded in keys.
+// The first algorithm is tried first when reading
+var compressionKeySuffixes = []struct {
+	algorithm CompressionAlgorithm
+	suffix    string
+}{
+	{CompressionGzip, ".gz"},
+	{CompressionZstd, ".zst"},
+}
+
+var errUnsupportedCompression = errors.New("compressed storage: unsupported compression")
+
+// encodedReader is implemented by drivers that can read the stored bytes of
+// an object along with its content encoding in a single request, without
+// transcoding such as the decompression done by GCS
+type encodedReader interface {
+	getEncoded(ctx context.Context, key string) (io.ReadCloser, string, error)
+}
+
+type CompressedStorageConfig struct {
+	// Algorithm used to compress new objects. Defaults to gzip
+	Algorithm CompressionAlgorithm
+
+	// Record the algorithm as a key suffix such as .gz instead of the
+	// Content-Encoding of the object. Required when the storage does not
+	// implement OptionsStorageWriter and ObjectStorage
+	KeySuffix bool
+}
+
+type compressedStorage struct {
+	storage StorageWriter
+	config  CompressedStorageConfig
+	suffix  string
+}
+
+// NewCompressedStorage returns a storage that compresses objects before
+// writing them to storage and decompresses them on read. Objects written
+// without compression are read as is
+func NewCompressedStorage(storage StorageWriter, config CompressedStorageConfig) (ContextStorageWriter, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("compressed storage: storage is required")
+	}
+
+	if config.Algorithm == "" {
+		config.Algorithm = CompressionGzip
+	}
+
+	s := &compressedStorage{storage: storage, config: config}
+	for _, encoding := range compressionKeySuffixes {
+		if encoding.algorithm == config.Algorithm {
+			s.suffix = encoding.suffix
+		}
+	}
+
+	if s.suffix == "" {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedCompression, config.Algorithm)
+	}
+
+	if !config.KeySuffix {
+		_, writesMetadata := storage.(OptionsStorageWriter)
+		_, readsMetadata := storage.(ObjectStorage)
+
+		if !writesMetadata || !readsMetadata {
+			return nil, fmt.Errorf("compressed storage: storage does not support metadata, use key suffixes")
+		}
+	}
+
+	return s, nil
+}
+
+func (s *compressedStorage) Put(key string, reader io.Reader) error {
+	return s.PutContext(context.Background(), key, reader)
+}
+
+func (s *compressedStorage) Get(key string) (io.ReadCloser, error) {
+	return s.GetContext(context.Background(), key)
+}
+
+func (s *compressedStorage) Writer(key string) (io.WriteCloser, error) {
+	return s.WriterContext(context.Background(), key)
+}
+
+func (s *compressedStorage) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer, err := s.WriterContext(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		// Cancel first so that context aware drivers abort the write
+		cancel()
+		_ = writer.Close()
+
+		return fmt.Errorf("compressed storage: failed to write: %w", err)
+	}
+
+	return writer.Close()
+}
+
+// GetContext finds the encoding of an object from its key suffix or its
+// metadata. Reading the metadata of storages other than the drivers of this
+// package takes an additional request
+func (s *compressedStorage) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	if s.config.KeySuffix {
+		return s.getWithKeySuffix(ctx, key)
+	}
+
+	if storage, ok := s.storage.(encodedReader); ok {
+		reader, encoding, err := storage.getEncoded(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		return decompress(CompressionAlgorithm(encoding), reader)
+	}
+
+	info, err := s.storage.(ObjectStorage).Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := getContext(ctx, s.storage, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return decompress(CompressionAlgorithm(info.ContentEncoding), reader)
+}
+
+func (s *compressedStorage) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	var writer io.WriteCloser
+	var err error
+
+	if s.config.KeySuffix {
+		key, err = s.suffixedKey(key, s.suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		writer, err = writerContext(ctx, s.storage, key)
+	} else {
+		writer, err = s.storage.(OptionsStorageWriter).WriterWithOptions(ctx, key, PutOptions{
+			ContentEncoding: string(s.config.Algorithm),
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compress(s.config.Algorithm, writer)
+}
+
+// getWithKeySuffix tries the suffix of the configured algorithm first so
+// that objects written before changing the algorithm can still be read.
+// Objects written without compression are read from the key itself
+func (s *compressedStorage) getWithKeySuffix(ctx context.Context, key string) (io.ReadCloser, error) {
+	algorithms := []CompressionAlgorithm{s.config.Algorithm}
+	suffixes := []string{s.suffix}
+
+	for _, encoding := range compressionKeySuffixes {
+		if encoding.algorithm != s.config.Algorithm {
+			algorithms = append(algorithms, encoding.algorithm)
+			suffixes = append(suffixes, encoding.suffix)
+		}
+	}
+
+	for i, suffix := range suffixes {
+		suffixedKey, err := s.suffixedKey(key, suffix)
+		if err != nil {
+			return nil, err
+		}
+
+		reader, err := getContext(ctx, s.storage, suffixedKey)
+		if err == nil {
+			return decompress(algorithms[i], reader)
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	reader, err := getContext(ctx, s.storage, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("compressed storage: %s: %w", key, ErrNotFound)
+		}
+
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// suffixedKey appends suffix to the key normalized the same way as the
+// drivers, so that keys such as "/" are not turned into "/.gz"
+func (s *compressedStorage) suffixedKey(key, suffix string) (string, error) {
+	key = strings.Trim(key, "/")
+	if key == "" {
+		return "", fmt.Errorf("compressed storage: key cannot be empty")
+	}
+
+	return key + suffix, nil
+}
+
+func compress(algorithm CompressionAlgorithm, writer io.WriteCloser) (io.WriteCloser, error) {
+	var compressor io.WriteCloser
+	switch algorithm {
+	case CompressionGzip:
+		compressor = gzip.NewWriter(writer)
+	case CompressionZstd:
+		encoder, err := zstd.NewWriter(writer)
+		if err != nil {
+			_ = writer.Close()
+			return nil, fmt.Errorf("compressed storage: failed to create zstd encoder: %w", err)
+		}
+
+		compressor = encoder
+	default:
+		_ = writer.Close()
+		return nil, fmt.Errorf("%w: %q", errUnsupportedCompression, algorithm)
+	}
+
+	return &compressingWriter{compressor: compressor, writer: writer}, nil
+}
+
+// decompress wraps reader with a decompressor for algorithm. Objects with
+// no or identity encoding are returned as is
+func decompress(algorithm CompressionAlgorithm, reader io.ReadCloser) (io.ReadCloser, error) {
+	switch algorithm {
+	case "", "identity":
+		return reader, nil
+	case CompressionGzip:
+		decompressor, err := gzip.NewReader(reader)
+		if err != nil {
+			_ = reader.Close()
+			return nil, fmt.Errorf("compressed storage: failed to read gzip header: %w", err)
+		}
+
+		return &decompressingReader{decompressor: decompressor, source: reader}, nil
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			_ = reader.Close()
+			return nil, fmt.Errorf("compressed storage: failed to create zstd decoder: %w", err)
+		}
+
+		return &decompressingReader{decompressor: decoder.IOReadCloser(), source: reader}, nil
+	default:
+		_ = reader.Close()
+		return nil, fmt.Errorf("%w: %q", errUnsupportedCompression, algorithm)
+	}
+}
+
+type compressingWriter struct {
+	compressor io.WriteCloser
+	writer     io.WriteCloser
+}
+
+func (w *compressingWriter) Write(p []byte) (int, error) {
+	return w.compressor.Write(p)
+}
+
+// Close flushes the compressor before closing the underlying writer
+func (w *compressingWriter) Close() error {
+	if err := w.compressor.Close(); err != nil {
+		_ = w.writer.Close()
+		return fmt.Errorf("compressed storage: failed to flush: %w", err)
+	}
+
+	return w.writer.Close()
+}
+
+type decompressingReader struct {
+	decompressor io.ReadCloser
+	source       io.ReadCloser
+}
+
+func (r *decompressingReader) Read(p []byte) (int, error) {
+	return r.decompressor.Read(p)
+}
+
+func (r *decompressingReader) Close() error {
+	_ = r.decompressor.Close()
+	return r.source.Close()
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedStorage(t *testing.T) {
+	content := []byte(strings.Repeat("compressible content ", 1024))
+
+	cases := []struct {
+		name      string
+		config    CompressedStorageConfig
+		storedKey string
+		magic     []byte
+		encoding  string
+	}{
+		{
+			name:      "gzip by default with metadata",
+			config:    CompressedStorageConfig{},
+			storedKey: "key",
+			magic:     []byte{0x1f, 0x8b},
+			encoding:  "gzip",
+		},
+		{
+			name:      "zstd with metadata",
+			config:    CompressedStorageConfig{Algorithm: CompressionZstd},
+			storedKey: "key",
+			magic:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+			encoding:  "zstd",
+		},
+		{
+			name:      "gzip with key suffix",
+			config:    CompressedStorageConfig{Algorithm: CompressionGzip, KeySuffix: true},
+			storedKey: "key.gz",
+			magic:     []byte{0x1f, 0x8b},
+		},
+		{
+			name:      "zstd with key suffix",
+			config:    CompressedStorageConfig{Algorithm: CompressionZstd, KeySuffix: true},
+			storedKey: "key.zst",
+			magic:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			backend := NewMemoryStorageDriver()
+
+			s, err := NewCompressedStorage(backend, test.config)
+			require.NoError(t, err)
+
+			require.NoError(t, s.Put("key", bytes.NewReader(content)))
+
+			data, err := readAllFrom(t, s, "key")
+			require.NoError(t, err)
+			assert.Equal(t, content, data)
+
+			stored, err := readAllFrom(t, backend, test.storedKey)
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(stored, test.magic))
+			assert.Less(t, len(stored), len(content))
+
+			info, err := backend.(ObjectStorage).Stat(context.Background(), test.storedKey)
+			require.NoError(t, err)
+			assert.Equal(t, test.encoding, info.ContentEncoding)
+		})
+	}
+}
+
+func TestCompressedStorageReadsUncompressedObjects(t *testing.T) {
+	backend := NewMemoryStorageDriver()
+	require.NoError(t, backend.Put("legacy", strings.NewReader("plain")))
+
+	for _, config := range []CompressedStorageConfig{{}, {KeySuffix: true}} {
+		s, err := NewCompressedStorage(backend, config)
+		require.NoError(t, err)
+
+		data, err := readAllFrom(t, s, "legacy")
+		require.NoError(t, err)
+		assert.Equal(t, "plain", string(data))
+	}
+
+	// With key suffixes, compressed objects take precedence over the key
+	s, err := NewCompressedStorage(backend, CompressedStorageConfig{KeySuffix: true})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put("legacy", strings.NewReader("compressed")))
+
+	data, err := readAllFrom(t, s, "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "compressed", string(data))
+}
+
+// statFailingStorage fails Stat to verify that reads of the drivers do not
+// take an additional request
+type statFailingStorage struct {
+	metadataStorage
+}
+
+type metadataStorage interface {
+	ObjectStorage
+	OptionsStorageWriter
+	encodedReader
+}
+
+func (s statFailingStorage) Stat(context.Context, string) (*ObjectInfo, error) {
+	return nil, errors.New("unexpected stat")
+}
+
+func TestCompressedStorageReadsEncodingWithObject(t *testing.T) {
+	drivers := map[string]func(t *testing.T) ObjectStorage{
+		"memory": func(t *testing.T) ObjectStorage { return NewMemoryStorageDriver() },
+		"fs": func(t *testing.T) ObjectStorage {
+			driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir()})
+			require.NoError(t, err)
+			return driver
+		},
+	}
+
+	for name, newDriver := range drivers {
+		t.Run(name, func(t *testing.T) {
+			backend := statFailingStorage{metadataStorage: newDriver(t).(metadataStorage)}
+
+			gzipStorage, err := NewCompressedStorage(backend, CompressedStorageConfig{})
+			require.NoError(t, err)
+
+			zstdStorage, err := NewCompressedStorage(backend, CompressedStorageConfig{Algorithm: CompressionZstd})
+			require.NoError(t, err)
+
+			require.NoError(t, gzipStorage.Put("key", strings.NewReader("written with gzip")))
+
+			data, err := readAllFrom(t, zstdStorage, "key")
+			require.NoError(t, err)
+			assert.Equal(t, "written with gzip", string(data))
+
+			// Overwriting without compression removes the encoding
+			require.NoError(t, backend.Put("key", strings.NewReader("plain")))
+
+			data, err = readAllFrom(t, gzipStorage, "key")
+			require.NoError(t, err)
+			assert.Equal(t, "plain", string(data))
+		})
+	}
+}
+
+func TestCompressedStorageKeySuffixAlgorithmChange(t *testing.T) {
+	backend := NewMemoryStorageDriver()
+
+	gzipStorage, err := NewCompressedStorage(backend, CompressedStorageConfig{KeySuffix: true})
+	require.NoError(t, err)
+	require.NoError(t, gzipStorage.Put("key", strings.NewReader("written with gzip")))
+
+	zstdStorage, err := NewCompressedStorage(backend, CompressedStorageConfig{
+		Algorithm: CompressionZstd,
+		KeySuffix: true,
+	})
+	require.NoError(t, err)
+
+	data, err := readAllFrom(t, zstdStorage, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "written with gzip", string(data))
+
+	_, err = zstdStorage.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCompressedStorageErrors(t *testing.T) {
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := NewCompressedStorage(NewMemoryStorageDriver(), CompressedStorageConfig{Algorithm: "br"})
+		assert.ErrorIs(t, err, errUnsupportedCompression)
+	})
+
+	t.Run("metadata requires object storage", func(t *testing.T) {
+		backend, err := NewEncryptedStorage(NewMemoryStorageDriver(), EncryptedStorageConfig{
+			Key: newTestEncryptionKey("k1"),
+		})
+		require.NoError(t, err)
+
+		_, err = NewCompressedStorage(backend, CompressedStorageConfig{})
+		assert.Error(t, err)
+
+		_, err = NewCompressedStorage(backend, CompressedStorageConfig{KeySuffix: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown content encoding", func(t *testing.T) {
+		backend := NewMemoryStorageDriver()
+		require.NoError(t, backend.(OptionsStorageWriter).PutWithOptions(context.Background(), "key",
+			strings.NewReader("data"), PutOptions{ContentEncoding: "br"}))
+
+		s, err := NewCompressedStorage(backend, CompressedStorageConfig{})
+		require.NoError(t, err)
+
+		_, err = s.Get("key")
+		assert.ErrorIs(t, err, errUnsupportedCompression)
+	})
+
+	t.Run("corrupt object", func(t *testing.T) {
+		backend := NewMemoryStorageDriver()
+		require.NoError(t, backend.(OptionsStorageWriter).PutWithOptions(context.Background(), "key",
+			strings.NewReader("not gzip"), PutOptions{ContentEncoding: "gzip"}))
+
+		s, err := NewCompressedStorage(backend, CompressedStorageConfig{})
+		require.NoError(t, err)
+
+		reader, err := s.Get("key")
+		if err == nil {
+			_, err = io.ReadAll(reader)
+			_ = reader.Close()
+		}
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+package storage_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/safedep/dry/storage"
+	"github.com/safedep/dry/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystemStorageDriverConformance(t *testing.T) {
+	storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+		driver, err := storage.NewFilesystemStorageDriver(storage.FilesystemStorageDriverConfig{
+			Root: t.TempDir(),
+		})
+
+		require.NoError(t, err)
+		return driver
+	})
+}
+
+func TestFilesystemStorageDriverChecksumSidecarConformance(t *testing.T) {
+	storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+		driver, err := storage.NewFilesystemStorageDriver(storage.FilesystemStorageDriverConfig{
+			Root:            t.TempDir(),
+			ChecksumSidecar: true,
+		})
+
+		require.NoError(t, err)
+		return driver
+	})
+}
+
+func TestMemoryStorageDriverConformance(t *testing.T) {
+	storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+		return storage.NewMemoryStorageDriver()
+	})
+}
+
+func TestEncryptedStorageConformance(t *testing.T) {
+	storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+		s, err := storage.NewEncryptedStorage(storage.NewMemoryStorageDriver(), storage.EncryptedStorageConfig{
+			Key: storage.EncryptionKey{ID: "k1", Key: bytes.Repeat([]byte("k"), 32)},
+		})
+
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestReplicatedStorageConformance(t *testing.T) {
+	storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+		s, err := storage.NewReplicatedStorage(storage.ReplicatedStorageConfig{
+			Backends: []storage.ReplicaBackend{
+				{Name: "primary", Storage: storage.NewMemoryStorageDriver()},
+				{Name: "secondary", Storage: storage.NewMemoryStorageDriver()},
+			},
+		})
+
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestCompressedStorageConformance(t *testing.T) {
+	for _, keySuffix := range []bool{false, true} {
+		t.Run(fmt.Sprintf("KeySuffix=%t", keySuffix), func(t *testing.T) {
+			storagetest.RunConformanceTests(t, func(t *testing.T) storage.StorageWriter {
+				s, err := storage.NewCompressedStorage(storage.NewMemoryStorageDriver(), storage.CompressedStorageConfig{
+					Algorithm: storage.CompressionZstd,
+					KeySuffix: keySuffix,
+				})
+
+				require.NoError(t, err)
+				return s
+			})
+		})
+	}
+}
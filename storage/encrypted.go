@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encrypted objects start with a header followed by chunks sealed with
+// AES-256-GCM using a key derived from the master key and a per object
+// salt. Every chunk is authenticated with the header as additional data and
+// a nonce made of the chunk counter and a flag marking the last chunk, so
+// that reordered, truncated or extended objects fail to decrypt.
+//
+//	magic (4) | version (1) | chunk size (4) | salt (16) | key id length (1) | key id
+const (
+	encryptionMagic            = "DRYE"
+	encryptionVersion          = 1
+	encryptionSaltSize         = 16
+	encryptionKeySize          = 32
+	encryptionTagSize          = 16
+	encryptionNonceSize        = 12
+	encryptionDefaultChunkSize = 64 << 10
+	encryptionMaxChunkSize     = 16 << 20
+	encryptionKeyInfo          = "safedep/dry storage encryption v1"
+)
+
+var (
+	// ErrDecryptionFailed is returned when an object is not encrypted, is
+	// encrypted with an unknown key or has been tampered with
+	ErrDecryptionFailed = errors.New("storage: decryption failed")
+
+	errEncryptionInvalidKey   = errors.New("storage: invalid encryption key")
+	errEncryptionWriterClosed = errors.New("encrypted storage: writer is closed")
+)
+
+// EncryptionKey is an AES-256 master key identified by ID. The ID is
+// recorded in every object so that the key can be rotated
+type EncryptionKey struct {
+	ID string
+
+	// 32 bytes
+	Key []byte
+}
+
+type EncryptedStorageConfig struct {
+	// Key used to encrypt new objects
+	Key EncryptionKey
+
+	// Previous keys used only to decrypt objects written before a rotation
+	DecryptionKeys []EncryptionKey
+
+	// Size of plaintext chunks sealed independently. Defaults to 64 KiB
+	ChunkSize int
+}
+
+type encryptedStorage struct {
+	storage StorageWriter
+	config  EncryptedStorageConfig
+	keys    map[string][]byte
+}
+
+// NewEncryptedStorage returns a storage that encrypts objects before
+// writing them to storage and decrypts them on read. Content is processed
+// in chunks so that objects of any size are streamed
+func NewEncryptedStorage(storage StorageWriter, config EncryptedStorageConfig) (ContextStorageWriter, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("encrypted storage: storage is required")
+	}
+
+	if config.ChunkSize == 0 {
+		config.ChunkSize = encryptionDefaultChunkSize
+	}
+
+	if config.ChunkSize < 0 || config.ChunkSize > encryptionMaxChunkSize {
+		return nil, fmt.Errorf("encrypted storage: chunk size must be at most %d", encryptionMaxChunkSize)
+	}
+
+	keys := map[string][]byte{}
+	for _, key := range append([]EncryptionKey{config.Key}, config.DecryptionKeys...) {
+		if key.ID == "" || len(key.ID) > 255 || len(key.Key) != encryptionKeySize {
+			return nil, fmt.Errorf("%w: %q must have an id and %d bytes",
+				errEncryptionInvalidKey, key.ID, encryptionKeySize)
+		}
+
+		keys[key.ID] = key.Key
+	}
+
+	return &encryptedStorage{storage: storage, config: config, keys: keys}, nil
+}
+
+func (s *encryptedStorage) Put(key string, reader io.Reader) error {
+	return s.PutContext(context.Background(), key, reader)
+}
+
+func (s *encryptedStorage) Get(key string) (io.ReadCloser, error) {
+	return s.GetContext(context.Background(), key)
+}
+
+func (s *encryptedStorage) Writer(key string) (io.WriteCloser, error) {
+	return s.WriterContext(context.Background(), key)
+}
+
+func (s *encryptedStorage) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer, err := s.WriterContext(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		// Cancel first so that the last chunk is not sealed and context
+		// aware drivers abort the write. Other drivers store an object
+		// without a last chunk, which fails decryption as truncated
+		cancel()
+		_ = writer.Close()
+
+		return fmt.Errorf("encrypted storage: failed to write: %w", err)
+	}
+
+	return writer.Close()
+}
+
+func (s *encryptedStorage) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := getContext(ctx, s.storage, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptingReader{source: reader, reader: bufio.NewReader(reader), keys: s.keys}, nil
+}
+
+func (s *encryptedStorage) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("encrypted storage: failed to generate salt: %w", err)
+	}
+
+	header := encodeEncryptionHeader(s.config.ChunkSize, salt, s.config.Key.ID)
+
+	aead, err := newChunkAEAD(s.config.Key.Key, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := writerContext(ctx, s.storage, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(header); err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("encrypted storage: failed to write header: %w", err)
+	}
+
+	return &encryptingWriter{
+		ctx:    ctx,
+		writer: writer,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, s.config.ChunkSize),
+	}, nil
+}
+
+func encodeEncryptionHeader(chunkSize int, salt []byte, keyID string) []byte {
+	header := make([]byte, 0, 4+1+4+encryptionSaltSize+1+len(keyID))
+	header = append(header, encryptionMagic...)
+	header = append(header, encryptionVersion)
+	header = binary.BigEndian.AppendUint32(header, uint32(chunkSize))
+	header = append(header, salt...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+
+	return header
+}
+
+func newChunkAEAD(masterKey, salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, masterKey, salt, encryptionKeyInfo, encryptionKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("encrypted storage: failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, encryptionNonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+// encryptingWriter holds back a full chunk until more data is written or
+// the writer is closed, since only then it is known whether it is the last.
+// The last chunk is not sealed when ctx is cancelled, so that an aborted
+// write is never stored as a complete object
+type encryptingWriter struct {
+	ctx     context.Context
+	writer  io.WriteCloser
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint64
+	closed  bool
+	err     error
+}
+
+func (w *encryptingWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errEncryptionWriterClosed
+	}
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == cap(w.buf) {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+func (w *encryptingWriter) Close() error {
+	if w.closed {
+		return errEncryptionWriterClosed
+	}
+
+	w.closed = true
+	if w.err == nil {
+		w.err = w.ctx.Err()
+	}
+
+	if w.err != nil {
+		_ = w.writer.Close()
+		return w.err
+	}
+
+	if err := w.seal(true); err != nil {
+		_ = w.writer.Close()
+		return err
+	}
+
+	return w.writer.Close()
+}
+
+func (w *encryptingWriter) seal(last bool) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.counter, last), w.buf, w.header)
+	if _, err := w.writer.Write(sealed); err != nil {
+		w.err = fmt.Errorf("encrypted storage: failed to write chunk: %w", err)
+		return w.err
+	}
+
+	w.counter++
+	w.buf = w.buf[:0]
+
+	return nil
+}
+
+type decryptingReader struct {
+	source  io.ReadCloser
+	reader  *bufio.Reader
+	keys    map[string][]byte
+	aead    cipher.AEAD
+	header  []byte
+	chunk   []byte
+	plain   []byte
+	counter uint64
+	done    bool
+	err     error
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if err := r.next(); err != nil {
+			r.err = err
+		}
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+
+	return n, nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.source.Close()
+}
+
+// next decrypts the next chunk, reading the header first if required
+func (r *decryptingReader) next() error {
+	if r.aead == nil {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	}
+
+	n, err := io.ReadFull(r.reader, r.chunk)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: object is truncated", ErrDecryptionFailed)
+		}
+
+		return err
+	}
+
+	// A chunk is the last one when no data follows it
+	last := errors.Is(err, io.ErrUnexpectedEOF)
+	if !last {
+		if _, err := r.reader.Peek(1); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+
+			last = true
+		}
+	}
+
+	plain, err := r.aead.Open(r.chunk[:0:0], chunkNonce(r.counter, last), r.chunk[:n], r.header)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d failed authentication", ErrDecryptionFailed, r.counter)
+	}
+
+	r.counter++
+	r.plain = plain
+	r.done = last
+
+	return nil
+}
+
+func (r *decryptingReader) readHeader() error {
+	fixed := make([]byte, 4+1+4+encryptionSaltSize+1)
+	if _, err := io.ReadFull(r.reader, fixed); err != nil {
+		return fmt.Errorf("%w: failed to read header: %w", ErrDecryptionFailed, err)
+	}
+
+	if !bytes.Equal(fixed[:4], []byte(encryptionMagic)) || fixed[4] != encryptionVersion {
+		return fmt.Errorf("%w: unsupported object format", ErrDecryptionFailed)
+	}
+
+	chunkSize := binary.BigEndian.Uint32(fixed[5:9])
+	if chunkSize == 0 || chunkSize > encryptionMaxChunkSize {
+		return fmt.Errorf("%w: invalid chunk size %d", ErrDecryptionFailed, chunkSize)
+	}
+
+	salt := fixed[9 : 9+encryptionSaltSize]
+
+	keyID := make([]byte, fixed[len(fixed)-1])
+	if _, err := io.ReadFull(r.reader, keyID); err != nil {
+		return fmt.Errorf("%w: failed to read key id: %w", ErrDecryptionFailed, err)
+	}
+
+	masterKey, ok := r.keys[string(keyID)]
+	if !ok {
+		return fmt.Errorf("%w: unknown key id %q", ErrDecryptionFailed, keyID)
+	}
+
+	aead, err := newChunkAEAD(masterKey, salt)
+	if err != nil {
+		return err
+	}
+
+	r.aead = aead
+	r.header = append(fixed, keyID...)
+	r.chunk = make([]byte, int(chunkSize)+encryptionTagSize)
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEncryptionKey(id string) EncryptionKey {
+	return EncryptionKey{ID: id, Key: bytes.Repeat([]byte(id[:1]), encryptionKeySize)}
+}
+
+func newTestEncryptedStorage(t *testing.T, root string, config EncryptedStorageConfig) ContextStorageWriter {
+	t.Helper()
+
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	s, err := NewEncryptedStorage(driver, config)
+	require.NoError(t, err)
+
+	return s
+}
+
+func readAllFrom(t *testing.T, s Storage, key string) ([]byte, error) {
+	t.Helper()
+
+	reader, err := s.Get(key)
+	require.NoError(t, err)
+
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+func TestEncryptedStorageRoundTrip(t *testing.T) {
+	const chunkSize = 16
+
+	root := t.TempDir()
+	s := newTestEncryptedStorage(t, root, EncryptedStorageConfig{
+		Key:       newTestEncryptionKey("k1"),
+		ChunkSize: chunkSize,
+	})
+
+	cases := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"single byte", 1},
+		{"less than a chunk", chunkSize - 1},
+		{"exactly a chunk", chunkSize},
+		{"more than a chunk", chunkSize + 1},
+		{"multiple chunks", 3 * chunkSize},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte("s"), test.size)
+
+			require.NoError(t, s.Put("put.bin", bytes.NewReader(content)))
+
+			got, err := readAllFrom(t, s, "put.bin")
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+
+			w, err := s.WriterContext(context.Background(), "writer.bin")
+			require.NoError(t, err)
+
+			// Write one byte at a time to exercise chunk boundaries
+			for _, b := range content {
+				_, err := w.Write([]byte{b})
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, w.Close())
+
+			got, err = readAllFrom(t, s, "writer.bin")
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+func TestEncryptedStorageCiphertext(t *testing.T) {
+	root := t.TempDir()
+	s := newTestEncryptedStorage(t, root, EncryptedStorageConfig{Key: newTestEncryptionKey("k1")})
+
+	require.NoError(t, s.Put("sbom.json", strings.NewReader("customer sbom")))
+	require.NoError(t, s.Put("copy.json", strings.NewReader("customer sbom")))
+
+	first, err := os.ReadFile(filepath.Join(root, "sbom.json"))
+	require.NoError(t, err)
+
+	second, err := os.ReadFile(filepath.Join(root, "copy.json"))
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(first), "customer sbom")
+	assert.NotEqual(t, first, second)
+}
+
+func TestEncryptedStorageKeyRotation(t *testing.T) {
+	root := t.TempDir()
+
+	old := newTestEncryptedStorage(t, root, EncryptedStorageConfig{Key: newTestEncryptionKey("k1")})
+	require.NoError(t, old.Put("report.json", strings.NewReader("report")))
+
+	rotated := newTestEncryptedStorage(t, root, EncryptedStorageConfig{
+		Key:            newTestEncryptionKey("k2"),
+		DecryptionKeys: []EncryptionKey{newTestEncryptionKey("k1")},
+	})
+
+	got, err := readAllFrom(t, rotated, "report.json")
+	require.NoError(t, err)
+	assert.Equal(t, "report", string(got))
+
+	withoutOldKey := newTestEncryptedStorage(t, root, EncryptedStorageConfig{Key: newTestEncryptionKey("k2")})
+
+	_, err = readAllFrom(t, withoutOldKey, "report.json")
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	assert.ErrorContains(t, err, "unknown key id")
+}
+
+func TestEncryptedStorageDetectsTampering(t *testing.T) {
+	const chunkSize = 16
+
+	root := t.TempDir()
+	s := newTestEncryptedStorage(t, root, EncryptedStorageConfig{
+		Key:       newTestEncryptionKey("k1"),
+		ChunkSize: chunkSize,
+	})
+
+	require.NoError(t, s.Put("scan.json", bytes.NewReader(bytes.Repeat([]byte("x"), 3*chunkSize))))
+
+	original, err := os.ReadFile(filepath.Join(root, "scan.json"))
+	require.NoError(t, err)
+
+	sealedChunk := chunkSize + encryptionTagSize
+	cases := []struct {
+		name   string
+		mutate func([]byte) []byte
+	}{
+		{"flipped byte", func(b []byte) []byte {
+			b[len(b)-5] ^= 0xff
+			return b
+		}},
+		{"truncated last chunk", func(b []byte) []byte {
+			return b[:len(b)-encryptionTagSize]
+		}},
+		{"dropped last chunk", func(b []byte) []byte {
+			return b[:len(b)-sealedChunk]
+		}},
+		{"appended data", func(b []byte) []byte {
+			return append(b, 0)
+		}},
+		{"not encrypted", func([]byte) []byte {
+			return []byte("plain text content")
+		}},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			mutated := test.mutate(bytes.Clone(original))
+			require.NoError(t, os.WriteFile(filepath.Join(root, "tampered.json"), mutated, 0o644))
+
+			_, err := readAllFrom(t, s, "tampered.json")
+			assert.ErrorIs(t, err, ErrDecryptionFailed)
+		})
+	}
+}
+
+func TestEncryptedStorageFailedWriteIsNotComplete(t *testing.T) {
+	const chunkSize = 16
+
+	// Writers of storages that are not context aware commit on Close
+	backend := struct{ StorageWriter }{NewMemoryStorageDriver()}
+
+	s, err := NewEncryptedStorage(backend, EncryptedStorageConfig{
+		Key:       newTestEncryptionKey("k1"),
+		ChunkSize: chunkSize,
+	})
+	require.NoError(t, err)
+
+	content := io.MultiReader(bytes.NewReader(bytes.Repeat([]byte("x"), 2*chunkSize)), failingReader{})
+
+	err = s.Put("scan.json", content)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// The stored object has no last chunk and is not read as complete
+	_, err = readAllFrom(t, s, "scan.json")
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	// Writers are aborted the same way when ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w, err := s.WriterContext(ctx, "writer.json")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("partial"))
+	require.NoError(t, err)
+
+	cancel()
+	assert.ErrorIs(t, w.Close(), context.Canceled)
+
+	_, err = readAllFrom(t, s, "writer.json")
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestNewEncryptedStorageValidation(t *testing.T) {
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir()})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		config EncryptedStorageConfig
+	}{
+		{"missing key id", EncryptedStorageConfig{Key: EncryptionKey{Key: make([]byte, encryptionKeySize)}}},
+		{"short key", EncryptedStorageConfig{Key: EncryptionKey{ID: "k1", Key: []byte("short")}}},
+		{"invalid decryption key", EncryptedStorageConfig{
+			Key:            newTestEncryptionKey("k1"),
+			DecryptionKeys: []EncryptionKey{{ID: "k0"}},
+		}},
+		{"chunk size too large", EncryptedStorageConfig{
+			Key:       newTestEncryptionKey("k1"),
+			ChunkSize: encryptionMaxChunkSize + 1,
+		}},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewEncryptedStorage(driver, test.config)
+			assert.Error(t, err)
+		})
+	}
+}
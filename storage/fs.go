@@ -1,21 +1,63 @@
 package storage
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
+	"mime"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 )
 
 type FilesystemStorageDriverConfig struct {
 	Root string
+
+	// Store the sha256 digest of every file in a sidecar file next to it
+	// and verify it on Get. Keys ending with the sidecar suffix are
+	// rejected when enabled
+	ChecksumSidecar bool
 }
 
+const (
+	// Files are written to a temporary file in the same directory and
+	// renamed over the key once complete
+	fsTempFilePattern = ".fs-storage-*.tmp"
+
+	fsChecksumSuffix = ".sha256"
+
+	// Metadata of files written with PutOptions is stored in a sidecar
+	fsMetadataSuffix = ".fs-storage-meta"
+)
+
 type filesystemStorageDriver struct {
 	config FilesystemStorageDriverConfig
+
+	// Root with symlinks resolved, used to check that keys do not resolve
+	// outside of root through symlinks
+	realRoot string
+
+	// Serializes replacing files and their sidecars so that preconditions
+	// are evaluated against a consistent state within the process
+	m sync.Mutex
 }
 
-func NewFilesystemStorageDriver(config FilesystemStorageDriverConfig) (StorageWriter, error) {
+var (
+	_ ObjectStorage        = (*filesystemStorageDriver)(nil)
+	_ OptionsStorageWriter = (*filesystemStorageDriver)(nil)
+	_ SignedURLStorage     = (*filesystemStorageDriver)(nil)
+	_ encodedReader        = (*filesystemStorageDriver)(nil)
+)
+
+func NewFilesystemStorageDriver(config FilesystemStorageDriverConfig) (ObjectStorage, error) {
 	_, err := os.Stat(config.Root)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -28,49 +70,716 @@ func NewFilesystemStorageDriver(config FilesystemStorageDriverConfig) (StorageWr
 		}
 	}
 
-	return &filesystemStorageDriver{config: config}, nil
+	realRoot, err := filepath.EvalSymlinks(config.Root)
+	if err != nil {
+		return nil, fmt.Errorf("fs storage adapter: failed to resolve root: %w", err)
+	}
+
+	return &filesystemStorageDriver{config: config, realRoot: realRoot}, nil
 }
 
 func (d *filesystemStorageDriver) Put(key string, reader io.Reader) error {
-	path := filepath.Join(d.config.Root, key)
-	err := d.createParentDirs(path)
+	return d.PutContext(context.Background(), key, reader)
+}
+
+func (d *filesystemStorageDriver) Get(key string) (io.ReadCloser, error) {
+	return d.GetContext(context.Background(), key)
+}
+
+func (d *filesystemStorageDriver) Writer(key string) (io.WriteCloser, error) {
+	return d.WriterContext(context.Background(), key)
+}
+
+func (d *filesystemStorageDriver) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	return d.PutWithOptions(ctx, key, reader, PutOptions{})
+}
+
+// PutWithOptions writes the file. An existing file is left untouched when
+// reading fails, a precondition fails or ctx is cancelled during the copy
+func (d *filesystemStorageDriver) PutWithOptions(ctx context.Context, key string, reader io.Reader, opts PutOptions) error {
+	writer, err := d.writer(ctx, key, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		writer.abort()
+		return fmt.Errorf("fs storage adapter: failed to write file: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("fs storage adapter: failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// GetContext opens the file and reads its checksum sidecar while holding
+// the commit lock, so that a concurrent overwrite does not pair the content
+// with the digest of another version
+func (d *filesystemStorageDriver) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.open(ctx, key, path)
+}
+
+// open opens the file at path. The caller must hold the commit lock
+func (d *filesystemStorageDriver) open(ctx context.Context, key, path string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("fs storage adapter: %s: %w", key, ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("fs storage adapter: failed to open file: %w", err)
+	}
+
+	// Directories are created for nested keys and are not objects
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("fs storage adapter: failed to stat file: %w", err)
+		}
+
+		return nil, fmt.Errorf("fs storage adapter: %s: %w", key, ErrNotFound)
+	}
+
+	if !d.config.ChecksumSidecar {
+		return file, nil
+	}
+
+	digest, err := d.readChecksum(path)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	// Files written before the sidecar was enabled are not verified
+	if digest == "" {
+		return file, nil
+	}
+
+	return &digestVerifyingReader{reader: file, hash: sha256.New(), digest: digest}, nil
+}
+
+// getEncoded reads the file and its metadata sidecar while holding the
+// commit lock, so that the encoding matches the content
+func (d *filesystemStorageDriver) getEncoded(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	reader, err := d.open(ctx, key, path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	metadata, err := d.readMetadata(path)
+	if err != nil {
+		_ = reader.Close()
+		return nil, "", err
+	}
+
+	if metadata == nil {
+		return reader, "", nil
+	}
+
+	return reader, metadata.ContentEncoding, nil
+}
+
+func (d *filesystemStorageDriver) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	return d.writer(ctx, key, PutOptions{})
+}
+
+// WriterWithOptions emulates metadata with a sidecar file. Preconditions
+// are atomic within the process. Creating only if absent is also atomic
+// across processes since the file is linked instead of renamed
+func (d *filesystemStorageDriver) WriterWithOptions(ctx context.Context, key string, opts PutOptions) (io.WriteCloser, error) {
+	return d.writer(ctx, key, opts)
+}
+
+func (d *filesystemStorageDriver) writer(ctx context.Context, key string, opts PutOptions) (*fsWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.createParentDirs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), fsTempFilePattern)
+	if err != nil {
+		return nil, fmt.Errorf("fs storage adapter: failed to create file: %w", err)
+	}
+
+	w := &fsWriter{ctx: ctx, driver: d, file: file, key: d.key(key), path: path, opts: opts}
+	if d.config.ChecksumSidecar {
+		w.hash = sha256.New()
+	}
+
+	return w, nil
+}
+
+// fsWriter writes to a temporary file that is synced and renamed over the
+// key on Close, so that readers never observe a partially written file.
+// The temporary file is removed when the write fails or ctx is cancelled
+type fsWriter struct {
+	ctx    context.Context
+	driver *filesystemStorageDriver
+	file   *os.File
+	key    string
+	path   string
+	opts   PutOptions
+
+	// Set when a checksum sidecar is written
+	hash hash.Hash
+}
+
+func (w *fsWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := w.file.Write(p)
+	if w.hash != nil {
+		w.hash.Write(p[:n])
+	}
+
+	return n, err
+}
+
+func (w *fsWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		w.abort()
+		return err
+	}
+
+	if err := w.file.Sync(); err != nil {
+		w.abort()
+		return fmt.Errorf("fs storage adapter: failed to sync file: %w", err)
+	}
+
+	if err := w.file.Chmod(0644); err != nil {
+		w.abort()
+		return fmt.Errorf("fs storage adapter: failed to set file mode: %w", err)
+	}
+
+	if err := w.file.Close(); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("fs storage adapter: failed to close file: %w", err)
+	}
+
+	err := w.driver.commit(w)
+
+	// Removes the temporary file left behind by a failed commit or a link
+	_ = os.Remove(w.file.Name())
 	if err != nil {
 		return err
 	}
 
-	file, err := os.Create(path)
+	syncDir(filepath.Dir(w.path))
+	return nil
+}
+
+func (w *fsWriter) abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
+
+// commit moves the written file over the key once the preconditions are
+// satisfied and updates its sidecars
+func (d *filesystemStorageDriver) commit(w *fsWriter) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if w.opts.IfMatch != "" {
+		version, _, err := hashFile(w.path)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("fs storage adapter: %s does not exist: %w", w.key, ErrPreconditionFailed)
+			}
+
+			return err
+		}
+
+		if version != w.opts.IfMatch {
+			return fmt.Errorf("fs storage adapter: %s has changed: %w", w.key, ErrPreconditionFailed)
+		}
+	}
+
+	if w.opts.IfNoneMatch {
+		// Unlike rename, link fails when the file exists
+		if err := os.Link(w.file.Name(), w.path); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("fs storage adapter: %s exists: %w", w.key, ErrPreconditionFailed)
+			}
+
+			return fmt.Errorf("fs storage adapter: failed to link file: %w", err)
+		}
+	} else if err := os.Rename(w.file.Name(), w.path); err != nil {
+		return fmt.Errorf("fs storage adapter: failed to rename file: %w", err)
+	}
+
+	if err := d.writeMetadata(w.path, w.opts); err != nil {
+		return err
+	}
+
+	// A crash between the rename above and the sidecar write leaves a
+	// stale checksum that fails verification instead of serving content
+	// that was not verified
+	if w.hash != nil {
+		digest := Digest("sha256:" + hex.EncodeToString(w.hash.Sum(nil)))
+		if err := writeFileAtomic(w.path+fsChecksumSuffix, []byte(digest.String())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes a small file with the same guarantees as fsWriter
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), fsTempFilePattern)
 	if err != nil {
 		return fmt.Errorf("fs storage adapter: failed to create file: %w", err)
 	}
 
-	defer func() { _ = file.Close() }()
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err == nil {
+		err = file.Chmod(0644)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
 
-	_, err = io.Copy(file, reader)
 	if err != nil {
-		return fmt.Errorf("fs storage adapter: failed to write file: %w", err)
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("fs storage adapter: failed to write %s: %w", filepath.Base(path), err)
 	}
 
 	return nil
 }
 
-func (d *filesystemStorageDriver) Get(key string) (io.ReadCloser, error) {
-	path := filepath.Join(d.config.Root, key)
-	file, err := os.OpenFile(path, os.O_RDONLY, 0)
+// fsMetadata is the content of a metadata sidecar
+type fsMetadata struct {
+	ContentType     string            `json:"content_type,omitempty"`
+	ContentEncoding string            `json:"content_encoding,omitempty"`
+	CacheControl    string            `json:"cache_control,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// writeMetadata replaces the metadata sidecar of a file. Files written
+// without metadata have no sidecar
+func (d *filesystemStorageDriver) writeMetadata(path string, opts PutOptions) error {
+	if opts.ContentType == "" && opts.ContentEncoding == "" && opts.CacheControl == "" && len(opts.Metadata) == 0 {
+		err := os.Remove(path + fsMetadataSuffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("fs storage adapter: failed to delete metadata: %w", err)
+		}
+
+		return nil
+	}
+
+	data, err := json.Marshal(fsMetadata{
+		ContentType:     opts.ContentType,
+		ContentEncoding: opts.ContentEncoding,
+		CacheControl:    opts.CacheControl,
+		Metadata:        opts.Metadata,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("fs storage adapter: failed to open file: %w", err)
+		return fmt.Errorf("fs storage adapter: failed to encode metadata: %w", err)
 	}
 
-	return file, nil
+	return writeFileAtomic(path+fsMetadataSuffix, data)
 }
 
-func (d *filesystemStorageDriver) Writer(key string) (io.WriteCloser, error) {
-	path := filepath.Join(d.config.Root, key)
-	err := d.createParentDirs(path)
+// readMetadata returns the metadata sidecar of a file or nil when there is
+// no sidecar
+func (d *filesystemStorageDriver) readMetadata(path string) (*fsMetadata, error) {
+	data, err := os.ReadFile(path + fsMetadataSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("fs storage adapter: failed to read metadata: %w", err)
+	}
+
+	var metadata fsMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("fs storage adapter: invalid metadata sidecar: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// syncDir persists the directory entry of a renamed file. It is best
+// effort since not all platforms support syncing directories
+func syncDir(dir string) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+
+	_ = f.Sync()
+	_ = f.Close()
+}
+
+// List walks the directory tree under root. Pages are ordered by key and
+// the page token is the last key of the previous page
+func (d *filesystemStorageDriver) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	prefix := strings.TrimLeft(opts.Prefix, "/")
+
+	// Walk only the deepest directory that can contain matching keys
+	base := d.config.Root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir := filepath.FromSlash(prefix[:i])
+		if !filepath.IsLocal(dir) {
+			return nil, fmt.Errorf("fs storage adapter: prefix %q resolves outside root", opts.Prefix)
+		}
+
+		base = filepath.Join(d.config.Root, dir)
+	}
+
+	var keys []string
+	err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir() || d.isInternalFile(entry.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.config.Root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) && key > opts.PageToken {
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fs storage adapter: failed to list files: %w", err)
+	}
+
+	sort.Strings(keys)
+
+	result := &ListResult{}
+	if pageSize := listPageSize(opts); len(keys) > pageSize {
+		keys = keys[:pageSize]
+		result.NextPageToken = keys[pageSize-1]
+	}
+
+	for _, key := range keys {
+		info, err := os.Stat(filepath.Join(d.config.Root, filepath.FromSlash(key)))
+		if err != nil {
+			// Deleted while listing
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, fmt.Errorf("fs storage adapter: failed to stat file: %w", err)
+		}
+
+		result.Objects = append(result.Objects, d.objectInfo(key, info))
+	}
+
+	return result, nil
+}
+
+// Stat returns the metadata of a file. The checksum is computed by reading
+// the file
+func (d *filesystemStorageDriver) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, info, err := hashFile(path)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("fs storage adapter: %s: %w", key, ErrNotFound)
+		}
+
 		return nil, err
 	}
 
-	return os.Create(path)
+	metadata, err := d.readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	object := d.objectInfo(d.key(key), info)
+	object.Checksum = checksum
+	object.ChecksumAlgorithm = "sha256"
+	object.Version = checksum
+
+	if metadata != nil {
+		if metadata.ContentType != "" {
+			object.ContentType = metadata.ContentType
+		}
+
+		object.ContentEncoding = metadata.ContentEncoding
+		object.CacheControl = metadata.CacheControl
+		object.Metadata = metadata.Metadata
+	}
+
+	return &object, nil
+}
+
+// hashFile returns the hex encoded sha256 digest of a file, which is also
+// used as its version
+func hashFile(path string) (string, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, ErrNotFound
+		}
+
+		return "", nil, fmt.Errorf("fs storage adapter: failed to open file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", nil, fmt.Errorf("fs storage adapter: failed to stat file: %w", err)
+	}
+
+	if info.IsDir() {
+		return "", nil, ErrNotFound
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", nil, fmt.Errorf("fs storage adapter: failed to read file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), info, nil
+}
+
+// remove deletes a file and its sidecars
+func (d *filesystemStorageDriver) remove(path string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("fs storage adapter: failed to delete file: %w", err)
+	}
+
+	err = os.Remove(path + fsMetadataSuffix)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("fs storage adapter: failed to delete metadata: %w", err)
+	}
+
+	if d.config.ChecksumSidecar {
+		err = os.Remove(path + fsChecksumSuffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("fs storage adapter: failed to delete checksum: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a file along with parent directories left empty
+func (d *filesystemStorageDriver) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := d.remove(path); err != nil {
+		return err
+	}
+
+	root := filepath.Clean(d.config.Root)
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		// Fails when the directory is not empty
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (d *filesystemStorageDriver) Exists(ctx context.Context, key string) (bool, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("fs storage adapter: failed to stat file: %w", err)
+	}
+
+	return !info.IsDir(), nil
+}
+
+// SignedGetURL is not supported since files are not served over HTTP
+func (d *filesystemStorageDriver) SignedGetURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	return nil, fmt.Errorf("fs storage adapter: signed urls: %w", ErrUnsupported)
+}
+
+// SignedPutURL is not supported since files are not served over HTTP
+func (d *filesystemStorageDriver) SignedPutURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	return nil, fmt.Errorf("fs storage adapter: signed urls: %w", ErrUnsupported)
+}
+
+// key normalizes a key the same way as the object storage drivers, so that
+// leading and trailing slashes are ignored
+func (d *filesystemStorageDriver) key(key string) string {
+	return strings.Trim(filepath.ToSlash(key), "/")
+}
+
+// path maps a key to a file under root. Keys are rejected when they refer
+// to root itself, a path outside of root, including through symlinks, or a
+// file used internally by the driver
+func (d *filesystemStorageDriver) path(key string) (string, error) {
+	key = d.key(key)
+	if len(key) == 0 {
+		return "", fmt.Errorf("fs storage adapter: key cannot be empty")
+	}
+
+	rel := filepath.FromSlash(key)
+	if !filepath.IsLocal(rel) || filepath.Clean(rel) == "." {
+		return "", fmt.Errorf("fs storage adapter: key %q resolves outside root", key)
+	}
+
+	if d.isInternalFile(filepath.Base(rel)) {
+		return "", fmt.Errorf("fs storage adapter: key %q is reserved", key)
+	}
+
+	path := filepath.Join(d.config.Root, rel)
+	if err := d.checkSymlinks(path); err != nil {
+		return "", fmt.Errorf("fs storage adapter: key %q resolves outside root: %w", key, err)
+	}
+
+	return path, nil
+}
+
+// checkSymlinks resolves the deepest existing part of path, which is either
+// the file or the directory it is created in, and verifies that it is under
+// root. Symlinks swapped concurrently by other processes are not detected
+func (d *filesystemStorageDriver) checkSymlinks(path string) error {
+	for existing := path; ; existing = filepath.Dir(existing) {
+		if _, err := os.Lstat(existing); err != nil {
+			if errors.Is(err, fs.ErrNotExist) && existing != filepath.Dir(existing) {
+				continue
+			}
+
+			return err
+		}
+
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(d.realRoot, resolved)
+		if err != nil {
+			return err
+		}
+
+		if rel != "." && !filepath.IsLocal(rel) {
+			return fmt.Errorf("%s is outside of root", resolved)
+		}
+
+		return nil
+	}
+}
+
+// isInternalFile reports whether a file name is a temporary file or a
+// sidecar
+func (d *filesystemStorageDriver) isInternalFile(name string) bool {
+	if ok, _ := filepath.Match(fsTempFilePattern, name); ok || strings.HasSuffix(name, fsMetadataSuffix) {
+		return true
+	}
+
+	return d.config.ChecksumSidecar && strings.HasSuffix(name, fsChecksumSuffix)
+}
+
+// readChecksum returns the digest stored in the sidecar of a file or an
+// empty digest when there is no sidecar
+func (d *filesystemStorageDriver) readChecksum(path string) (Digest, error) {
+	data, err := os.ReadFile(path + fsChecksumSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("fs storage adapter: failed to read checksum: %w", err)
+	}
+
+	digest, err := ParseDigest(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("fs storage adapter: invalid checksum sidecar: %w", err)
+	}
+
+	return digest, nil
+}
+
+func (d *filesystemStorageDriver) objectInfo(key string, info os.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        info.Size(),
+		ModifiedAt:  info.ModTime(),
+		ContentType: mime.TypeByExtension(filepath.Ext(key)),
+	}
 }
 
 func (d *filesystemStorageDriver) createParentDirs(path string) error {
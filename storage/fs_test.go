@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 	"path/filepath"
@@ -8,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFilesystemStorageDriver(t *testing.T) {
@@ -79,3 +83,420 @@ func TestFilesystemStorageDriver(t *testing.T) {
 		_ = reader.Close()
 	})
 }
+
+func TestFilesystemStorageDriverObjects(t *testing.T) {
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir()})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, key := range []string{"a.txt", "dir/b.json", "dir/c.txt", "dir/sub/d.txt", "other/e.txt"} {
+		require.NoError(t, driver.Put(key, strings.NewReader(key)))
+	}
+
+	t.Run("List", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			prefix string
+			keys   []string
+		}{
+			{"all", "", []string{"a.txt", "dir/b.json", "dir/c.txt", "dir/sub/d.txt", "other/e.txt"}},
+			{"directory prefix", "dir/", []string{"dir/b.json", "dir/c.txt", "dir/sub/d.txt"}},
+			{"partial name prefix", "dir/s", []string{"dir/sub/d.txt"}},
+			{"missing prefix", "missing/", nil},
+		}
+
+		for _, test := range cases {
+			t.Run(test.name, func(t *testing.T) {
+				res, err := driver.List(ctx, ListOptions{Prefix: test.prefix})
+				require.NoError(t, err)
+
+				var keys []string
+				for _, object := range res.Objects {
+					keys = append(keys, object.Key)
+				}
+
+				assert.Equal(t, test.keys, keys)
+				assert.Empty(t, res.NextPageToken)
+			})
+		}
+	})
+
+	t.Run("List pages", func(t *testing.T) {
+		var keys []string
+		opts := ListOptions{Prefix: "dir/", PageSize: 2}
+
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 3)
+
+			res, err := driver.List(ctx, opts)
+			require.NoError(t, err)
+
+			for _, object := range res.Objects {
+				keys = append(keys, object.Key)
+			}
+
+			if res.NextPageToken == "" {
+				break
+			}
+
+			opts.PageToken = res.NextPageToken
+		}
+
+		assert.Equal(t, []string{"dir/b.json", "dir/c.txt", "dir/sub/d.txt"}, keys)
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := driver.Stat(ctx, "dir/b.json")
+		require.NoError(t, err)
+
+		sum := sha256.Sum256([]byte("dir/b.json"))
+		assert.Equal(t, "dir/b.json", info.Key)
+		assert.Equal(t, int64(len("dir/b.json")), info.Size)
+		assert.Equal(t, "application/json", info.ContentType)
+		assert.Equal(t, hex.EncodeToString(sum[:]), info.Checksum)
+		assert.Equal(t, "sha256", info.ChecksumAlgorithm)
+		assert.False(t, info.ModifiedAt.IsZero())
+
+		_, err = driver.Stat(ctx, "missing.txt")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = driver.Stat(ctx, "dir")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Exists and Delete", func(t *testing.T) {
+		exists, err := driver.Exists(ctx, "dir/sub/d.txt")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		require.NoError(t, driver.Delete(ctx, "dir/sub/d.txt"))
+		require.NoError(t, driver.Delete(ctx, "dir/sub/d.txt"))
+
+		exists, err = driver.Exists(ctx, "dir/sub/d.txt")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		exists, err = driver.Exists(ctx, "dir")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		res, err := driver.List(ctx, ListOptions{Prefix: "dir/"})
+		require.NoError(t, err)
+		assert.Len(t, res.Objects, 2)
+	})
+}
+
+func TestFilesystemStorageDriverContext(t *testing.T) {
+	root := t.TempDir()
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	t.Run("PutContext and GetContext", func(t *testing.T) {
+		require.NoError(t, driver.PutContext(context.Background(), "ctx.txt", strings.NewReader("content")))
+
+		reader, err := driver.GetContext(context.Background(), "ctx.txt")
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+		assert.NoError(t, reader.Close())
+	})
+
+	t.Run("cancelled context fails fast", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, driver.PutContext(ctx, "cancelled.txt", strings.NewReader("content")), context.Canceled)
+
+		_, err := driver.GetContext(ctx, "ctx.txt")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = driver.WriterContext(ctx, "cancelled.txt")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = os.Stat(filepath.Join(root, "cancelled.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("cancelling a writer removes the partial file", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		writer, err := driver.WriterContext(ctx, "partial.txt")
+		require.NoError(t, err)
+
+		_, err = writer.Write([]byte("partial"))
+		require.NoError(t, err)
+
+		cancel()
+
+		_, err = writer.Write([]byte("more"))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, writer.Close(), context.Canceled)
+
+		_, err = os.Stat(filepath.Join(root, "partial.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestFilesystemStorageDriverSignedURLUnsupported(t *testing.T) {
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir()})
+	require.NoError(t, err)
+
+	signer, ok := driver.(SignedURLStorage)
+	require.True(t, ok)
+
+	_, err = signer.SignedGetURL(context.Background(), "report.json", SignedURLOptions{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = signer.SignedPutURL(context.Background(), "report.json", SignedURLOptions{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestFilesystemStorageDriverRejectsKeysOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0o644))
+
+	cases := []struct {
+		name string
+		key  string
+	}{
+		{"parent directory", "../secret.txt"},
+		{"nested parent directory", "a/../../secret.txt"},
+		{"root itself", "a/.."},
+		{"dot", "."},
+		{"temporary file", "a/.fs-storage-123.tmp"},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, driver.Put(test.key, strings.NewReader("overwritten")))
+
+			_, err := driver.Get(test.key)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrNotFound)
+
+			assert.Error(t, driver.Delete(context.Background(), test.key))
+		})
+	}
+
+	_, err = driver.List(context.Background(), ListOptions{Prefix: "../"})
+	assert.Error(t, err)
+
+	content, err := os.ReadFile(filepath.Join(parent, "secret.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+}
+
+func TestFilesystemStorageDriverRejectsSymlinksOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+	outside := filepath.Join(parent, "outside")
+
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir(outside, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644))
+
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret.txt")))
+	require.NoError(t, os.Symlink(filepath.Join(parent, "missing"), filepath.Join(root, "dangling")))
+
+	cases := []struct {
+		name string
+		key  string
+	}{
+		{"file in linked directory", "link/secret.txt"},
+		{"new file in linked directory", "link/new.txt"},
+		{"new nested file in linked directory", "link/a/new.txt"},
+		{"linked file", "secret.txt"},
+		{"dangling link", "dangling/new.txt"},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, driver.Put(test.key, strings.NewReader("overwritten")))
+
+			_, err := driver.Get(test.key)
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrNotFound)
+		})
+	}
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	content, err := os.ReadFile(filepath.Join(outside, "secret.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+
+	t.Run("links within root are allowed", func(t *testing.T) {
+		require.NoError(t, driver.Put("dir/file.txt", strings.NewReader("content")))
+		require.NoError(t, os.Symlink(filepath.Join(root, "dir"), filepath.Join(root, "alias")))
+
+		data, err := readAllFrom(t, driver, "alias/file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(data))
+	})
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestFilesystemStorageDriverAtomicWrites(t *testing.T) {
+	root := t.TempDir()
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	require.NoError(t, driver.Put("a/report.json", strings.NewReader("original")))
+
+	// A failed write keeps the previous content
+	err = driver.Put("a/report.json", io.MultiReader(strings.NewReader("partial"), failingReader{}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	content, err := readAllFrom(t, driver, "a/report.json")
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+
+	// Content is not visible until the writer is closed
+	writer, err := driver.Writer("a/report.json")
+	require.NoError(t, err)
+
+	_, err = writer.Write([]byte("updated"))
+	require.NoError(t, err)
+
+	content, err = readAllFrom(t, driver, "a/report.json")
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+
+	res, err := driver.List(context.Background(), ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.Objects, 1)
+
+	require.NoError(t, writer.Close())
+
+	content, err = readAllFrom(t, driver, "a/report.json")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", string(content))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Join(root, "a"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "report.json", entries[0].Name())
+}
+
+func TestFilesystemStorageDriverChecksumSidecar(t *testing.T) {
+	root := t.TempDir()
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root, ChecksumSidecar: true})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, driver.Put("scan.json", strings.NewReader("scan result")))
+
+	sum := sha256.Sum256([]byte("scan result"))
+	sidecar, err := os.ReadFile(filepath.Join(root, "scan.json.sha256"))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), string(sidecar))
+
+	content, err := readAllFrom(t, driver, "scan.json")
+	require.NoError(t, err)
+	assert.Equal(t, "scan result", string(content))
+
+	// Sidecars are not objects
+	res, err := driver.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.Objects, 1)
+	assert.Equal(t, "scan.json", res.Objects[0].Key)
+
+	assert.Error(t, driver.Put("scan.json.sha256", strings.NewReader("x")))
+
+	// Files modified outside of the driver fail verification
+	require.NoError(t, os.WriteFile(filepath.Join(root, "scan.json"), []byte("scan resul"), 0o644))
+
+	_, err = readAllFrom(t, driver, "scan.json")
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	// Files without a sidecar are not verified
+	require.NoError(t, os.WriteFile(filepath.Join(root, "legacy.json"), []byte("legacy"), 0o644))
+
+	content, err = readAllFrom(t, driver, "legacy.json")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", string(content))
+
+	require.NoError(t, driver.Delete(ctx, "scan.json"))
+
+	_, err = os.Stat(filepath.Join(root, "scan.json.sha256"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFilesystemStorageDriverChecksumSidecarConcurrentOverwrite(t *testing.T) {
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: t.TempDir(), ChecksumSidecar: true})
+	require.NoError(t, err)
+
+	versions := []string{"first version", "second version, which is longer"}
+	require.NoError(t, driver.Put("scan.json", strings.NewReader(versions[0])))
+
+	done := make(chan struct{})
+	t.Cleanup(func() { <-done })
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 200; i++ {
+			assert.NoError(t, driver.Put("scan.json", strings.NewReader(versions[i%2])))
+		}
+	}()
+
+	// Readers never pair the content with the digest of another version
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		content, err := readAllFrom(t, driver, "scan.json")
+		require.NoError(t, err)
+		assert.Contains(t, versions, string(content))
+	}
+}
+
+func TestFilesystemStorageDriverMetadataSidecar(t *testing.T) {
+	root := t.TempDir()
+	driver, err := NewFilesystemStorageDriver(FilesystemStorageDriverConfig{Root: root})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	writer := driver.(OptionsStorageWriter)
+
+	require.NoError(t, writer.PutWithOptions(ctx, "pkg/index.json", strings.NewReader("{}"), PutOptions{
+		ContentEncoding: "gzip",
+		Metadata:        map[string]string{"ecosystem": "npm"},
+	}))
+
+	_, err = os.Stat(filepath.Join(root, "pkg", "index.json"+fsMetadataSuffix))
+	require.NoError(t, err)
+
+	res, err := driver.List(ctx, ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, res.Objects, 1)
+	assert.Equal(t, "pkg/index.json", res.Objects[0].Key)
+
+	assert.Error(t, driver.Put("pkg/index.json"+fsMetadataSuffix, strings.NewReader("x")))
+
+	require.NoError(t, driver.Delete(ctx, "pkg/index.json"))
+
+	_, err = os.Stat(filepath.Join(root, "pkg"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -2,12 +2,19 @@ package storage
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -36,7 +43,12 @@ func WithGoogleStorageWriteTimeout(timeout time.Duration) googleCloudStorageDriv
 	}
 }
 
-var _ StorageWriter = (*googleCloudStorageDriver)(nil)
+var (
+	_ ObjectStorage        = (*googleCloudStorageDriver)(nil)
+	_ OptionsStorageWriter = (*googleCloudStorageDriver)(nil)
+	_ SignedURLStorage     = (*googleCloudStorageDriver)(nil)
+	_ encodedReader        = (*googleCloudStorageDriver)(nil)
+)
 
 func NewGoogleCloudStorageDriver(config GoogleCloudStorageDriverConfig,
 	opts ...googleCloudStorageDriverOpts) (*googleCloudStorageDriver, error) {
@@ -65,12 +77,36 @@ func NewGoogleCloudStorageDriver(config GoogleCloudStorageDriverConfig,
 }
 
 func (d *googleCloudStorageDriver) Put(key string, reader io.Reader) error {
-	writer, err := d.Writer(key)
+	return d.PutContext(context.Background(), key, reader)
+}
+
+func (d *googleCloudStorageDriver) Get(key string) (io.ReadCloser, error) {
+	return d.GetContext(context.Background(), key)
+}
+
+func (d *googleCloudStorageDriver) Writer(key string) (io.WriteCloser, error) {
+	return d.WriterContext(context.Background(), key)
+}
+
+func (d *googleCloudStorageDriver) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	return d.PutWithOptions(ctx, key, reader, PutOptions{})
+}
+
+func (d *googleCloudStorageDriver) PutWithOptions(ctx context.Context, key string, reader io.Reader, opts PutOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer, err := d.WriterWithOptions(ctx, key, opts)
 	if err != nil {
 		return fmt.Errorf("failed to create google cloud storage writer: %w", err)
 	}
 
 	if _, err := io.Copy(writer, reader); err != nil {
+		// Closing after a failed copy would commit a partial object,
+		// cancelling first aborts the upload instead
+		cancel()
+		_ = writer.Close()
+
 		return fmt.Errorf("failed to write to google cloud storage: %w", err)
 	}
 
@@ -81,31 +117,252 @@ func (d *googleCloudStorageDriver) Put(key string, reader io.Reader) error {
 	return nil
 }
 
-func (d *googleCloudStorageDriver) Get(key string) (io.ReadCloser, error) {
+func (d *googleCloudStorageDriver) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := d.newReader(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// getEncoded returns the stored bytes of the object with its encoding.
+// Objects uploaded with a Content-Encoding such as gzip are not
+// decompressed by GCS, unlike with GetContext
+func (d *googleCloudStorageDriver) getEncoded(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	reader, err := d.newReader(ctx, key, true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return reader, reader.Attrs.ContentEncoding, nil
+}
+
+func (d *googleCloudStorageDriver) newReader(ctx context.Context, key string, compressed bool) (*storage.Reader, error) {
 	keyName, err := d.prefix(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prefix key: %w", err)
 	}
 
-	object := d.client.Bucket(d.config.BucketName).Object(keyName)
-	reader, err := object.NewReader(context.Background())
+	object := d.client.Bucket(d.config.BucketName).Object(keyName).ReadCompressed(compressed)
+	reader, err := object.NewReader(ctx)
 	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, fmt.Errorf("google cloud storage: %s: %w", keyName, ErrNotFound)
+		}
+
 		return nil, fmt.Errorf("failed to create google cloud storage reader: %w", err)
 	}
 
 	return reader, nil
 }
 
-func (d *googleCloudStorageDriver) Writer(key string) (io.WriteCloser, error) {
+// WriterContext returns a writer that uploads to GCS. The upload is aborted
+// and no object is created when ctx is cancelled before Close
+func (d *googleCloudStorageDriver) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	return d.WriterWithOptions(ctx, key, PutOptions{})
+}
+
+// WriterWithOptions uses GCS preconditions. The version used with IfMatch
+// is the generation of the object
+func (d *googleCloudStorageDriver) WriterWithOptions(ctx context.Context, key string, opts PutOptions) (io.WriteCloser, error) {
 	keyName, err := d.prefix(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prefix key: %w", err)
 	}
 
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	object := d.client.Bucket(d.config.BucketName).Object(keyName)
-	writer := object.NewWriter(context.Background())
 
-	return writer, nil
+	if opts.IfNoneMatch {
+		object = object.If(storage.Conditions{DoesNotExist: true})
+	}
+
+	if opts.IfMatch != "" {
+		generation, err := strconv.ParseInt(opts.IfMatch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("google cloud storage: invalid version %q: %w", opts.IfMatch, ErrPreconditionFailed)
+		}
+
+		object = object.If(storage.Conditions{GenerationMatch: generation})
+	}
+
+	writer := object.NewWriter(ctx)
+	writer.ContentType = opts.ContentType
+	writer.ContentEncoding = opts.ContentEncoding
+	writer.CacheControl = opts.CacheControl
+	writer.Metadata = opts.Metadata
+
+	return &gcsWriter{writer: writer}, nil
+}
+
+// gcsWriter reports rejected preconditions as ErrPreconditionFailed
+type gcsWriter struct {
+	writer *storage.Writer
+}
+
+func (w *gcsWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	return n, gcsPreconditionError(err)
+}
+
+func (w *gcsWriter) Close() error {
+	return gcsPreconditionError(w.writer.Close())
+}
+
+func gcsPreconditionError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+
+	return err
+}
+
+func (d *googleCloudStorageDriver) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	it := d.client.Bucket(d.config.BucketName).Objects(ctx, &storage.Query{
+		Prefix: strings.TrimLeft(opts.Prefix, "/"),
+	})
+
+	var attrs []*storage.ObjectAttrs
+	token, err := iterator.NewPager(it, listPageSize(opts), opts.PageToken).NextPage(&attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list google cloud storage objects: %w", err)
+	}
+
+	result := &ListResult{NextPageToken: token}
+	for _, attr := range attrs {
+		result.Objects = append(result.Objects, gcsObjectInfo(attr))
+	}
+
+	return result, nil
+}
+
+func (d *googleCloudStorageDriver) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prefix key: %w", err)
+	}
+
+	attrs, err := d.client.Bucket(d.config.BucketName).Object(keyName).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, fmt.Errorf("google cloud storage: %s: %w", keyName, ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("failed to get google cloud storage object attributes: %w", err)
+	}
+
+	object := gcsObjectInfo(attrs)
+	return &object, nil
+}
+
+func (d *googleCloudStorageDriver) Delete(ctx context.Context, key string) error {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return fmt.Errorf("failed to prefix key: %w", err)
+	}
+
+	err = d.client.Bucket(d.config.BucketName).Object(keyName).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete google cloud storage object: %w", err)
+	}
+
+	return nil
+}
+
+func (d *googleCloudStorageDriver) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := d.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SignedGetURL returns a V4 signed URL. The content type, when set,
+// overrides the Content-Type of the response
+func (d *googleCloudStorageDriver) SignedGetURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	var query url.Values
+	if opts.ContentType != "" {
+		query = url.Values{"response-content-type": []string{opts.ContentType}}
+	}
+
+	return d.signedURL(key, http.MethodGet, opts, &storage.SignedURLOptions{QueryParameters: query})
+}
+
+// SignedPutURL returns a V4 signed URL. The content type, when set, is
+// signed and the upload must send the same Content-Type header
+func (d *googleCloudStorageDriver) SignedPutURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	return d.signedURL(key, http.MethodPut, opts, &storage.SignedURLOptions{ContentType: opts.ContentType})
+}
+
+// signedURL signs with the credentials of the client. Service account keys
+// are used directly, other credentials sign through the IAM credentials API
+func (d *googleCloudStorageDriver) signedURL(key, method string, opts SignedURLOptions,
+	signOpts *storage.SignedURLOptions) (*SignedURL, error) {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prefix key: %w", err)
+	}
+
+	expiry, err := signedURLExpiry(opts)
+	if err != nil {
+		return nil, fmt.Errorf("google cloud storage: %w", err)
+	}
+
+	signOpts.Scheme = storage.SigningSchemeV4
+	signOpts.Method = method
+	signOpts.Expires = time.Now().Add(expiry)
+
+	signed, err := d.client.Bucket(d.config.BucketName).SignedURL(keyName, signOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign google cloud storage url: %w", err)
+	}
+
+	headers := http.Header{}
+	if method == http.MethodPut && opts.ContentType != "" {
+		headers.Set("Content-Type", opts.ContentType)
+	}
+
+	return &SignedURL{
+		URL:       signed,
+		Method:    method,
+		Headers:   headers,
+		ExpiresAt: signOpts.Expires,
+	}, nil
+}
+
+// gcsObjectInfo maps object attributes. Composite objects have no MD5 and
+// report CRC32C instead
+func gcsObjectInfo(attrs *storage.ObjectAttrs) ObjectInfo {
+	object := ObjectInfo{
+		Key:             attrs.Name,
+		Size:            attrs.Size,
+		ModifiedAt:      attrs.Updated,
+		ContentType:     attrs.ContentType,
+		ContentEncoding: attrs.ContentEncoding,
+		CacheControl:    attrs.CacheControl,
+		Metadata:        attrs.Metadata,
+		Version:         strconv.FormatInt(attrs.Generation, 10),
+	}
+
+	if len(attrs.MD5) > 0 {
+		object.Checksum = hex.EncodeToString(attrs.MD5)
+		object.ChecksumAlgorithm = "md5"
+	} else {
+		object.Checksum = fmt.Sprintf("%08x", attrs.CRC32C)
+		object.ChecksumAlgorithm = "crc32c"
+	}
+
+	return object
 }
 
 func (d *googleCloudStorageDriver) prefix(key string) (string, error) {
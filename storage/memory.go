@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errMemoryWriterClosed = errors.New("memory storage adapter: writer is closed")
+
+type memoryObject struct {
+	data       []byte
+	modifiedAt time.Time
+	options    PutOptions
+}
+
+type memoryStorageDriver struct {
+	m       sync.RWMutex
+	objects map[string]memoryObject
+}
+
+var (
+	_ ObjectStorage        = (*memoryStorageDriver)(nil)
+	_ OptionsStorageWriter = (*memoryStorageDriver)(nil)
+	_ encodedReader        = (*memoryStorageDriver)(nil)
+)
+
+// NewMemoryStorageDriver returns a storage that keeps objects in memory.
+// It follows the same semantics as the other drivers and is meant to be
+// used as a fake in tests
+func NewMemoryStorageDriver() ObjectStorage {
+	return &memoryStorageDriver{objects: map[string]memoryObject{}}
+}
+
+func (d *memoryStorageDriver) Put(key string, reader io.Reader) error {
+	return d.PutContext(context.Background(), key, reader)
+}
+
+func (d *memoryStorageDriver) Get(key string) (io.ReadCloser, error) {
+	return d.GetContext(context.Background(), key)
+}
+
+func (d *memoryStorageDriver) Writer(key string) (io.WriteCloser, error) {
+	return d.WriterContext(context.Background(), key)
+}
+
+func (d *memoryStorageDriver) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	return d.PutWithOptions(ctx, key, reader, PutOptions{})
+}
+
+func (d *memoryStorageDriver) PutWithOptions(ctx context.Context, key string, reader io.Reader, opts PutOptions) error {
+	writer, err := d.WriterWithOptions(ctx, key, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		return fmt.Errorf("memory storage adapter: failed to write: %w", err)
+	}
+
+	return writer.Close()
+}
+
+func (d *memoryStorageDriver) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := d.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored data is never mutated, replacing an object swaps the slice
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+func (d *memoryStorageDriver) getEncoded(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	object, err := d.get(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), object.options.ContentEncoding, nil
+}
+
+func (d *memoryStorageDriver) get(ctx context.Context, key string) (memoryObject, error) {
+	if err := ctx.Err(); err != nil {
+		return memoryObject{}, err
+	}
+
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return memoryObject{}, err
+	}
+
+	d.m.RLock()
+	object, ok := d.objects[keyName]
+	d.m.RUnlock()
+
+	if !ok {
+		return memoryObject{}, fmt.Errorf("memory storage adapter: %s: %w", keyName, ErrNotFound)
+	}
+
+	return object, nil
+}
+
+func (d *memoryStorageDriver) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	return d.WriterWithOptions(ctx, key, PutOptions{})
+}
+
+// WriterWithOptions buffers writes and stores the object on Close. The
+// object is not stored when ctx is cancelled before Close
+func (d *memoryStorageDriver) WriterWithOptions(ctx context.Context, key string, opts PutOptions) (io.WriteCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryWriter{ctx: ctx, driver: d, key: keyName, opts: opts}, nil
+}
+
+func (d *memoryStorageDriver) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimLeft(opts.Prefix, "/")
+
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	var keys []string
+	for key := range d.objects {
+		if strings.HasPrefix(key, prefix) && key > opts.PageToken {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	result := &ListResult{}
+	if pageSize := listPageSize(opts); len(keys) > pageSize {
+		keys = keys[:pageSize]
+		result.NextPageToken = keys[pageSize-1]
+	}
+
+	for _, key := range keys {
+		result.Objects = append(result.Objects, d.objectInfo(key, d.objects[key]))
+	}
+
+	return result, nil
+}
+
+func (d *memoryStorageDriver) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, err
+	}
+
+	d.m.RLock()
+	object, ok := d.objects[keyName]
+	d.m.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("memory storage adapter: %s: %w", keyName, ErrNotFound)
+	}
+
+	info := d.objectInfo(keyName, object)
+	return &info, nil
+}
+
+func (d *memoryStorageDriver) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return err
+	}
+
+	d.m.Lock()
+	delete(d.objects, keyName)
+	d.m.Unlock()
+
+	return nil
+}
+
+func (d *memoryStorageDriver) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := d.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (d *memoryStorageDriver) objectInfo(key string, object memoryObject) ObjectInfo {
+	version := object.version()
+
+	contentType := object.options.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	return ObjectInfo{
+		Key:               key,
+		Size:              int64(len(object.data)),
+		ModifiedAt:        object.modifiedAt,
+		ContentType:       contentType,
+		ContentEncoding:   object.options.ContentEncoding,
+		CacheControl:      object.options.CacheControl,
+		Metadata:          maps.Clone(object.options.Metadata),
+		Checksum:          version,
+		ChecksumAlgorithm: "sha256",
+		Version:           version,
+	}
+}
+
+// version is the hex encoded sha256 digest of the content
+func (o memoryObject) version() string {
+	sum := sha256.Sum256(o.data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (d *memoryStorageDriver) prefix(key string) (string, error) {
+	key = strings.Trim(key, "/")
+	if len(key) == 0 {
+		return "", fmt.Errorf("memory storage adapter: key cannot be empty")
+	}
+
+	return key, nil
+}
+
+type memoryWriter struct {
+	ctx    context.Context
+	driver *memoryStorageDriver
+	key    string
+	opts   PutOptions
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errMemoryWriterClosed
+	}
+
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *memoryWriter) Close() error {
+	if w.closed {
+		return errMemoryWriterClosed
+	}
+
+	w.closed = true
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	w.driver.m.Lock()
+	defer w.driver.m.Unlock()
+
+	current, exists := w.driver.objects[w.key]
+	if w.opts.IfNoneMatch && exists {
+		return fmt.Errorf("memory storage adapter: %s exists: %w", w.key, ErrPreconditionFailed)
+	}
+
+	if w.opts.IfMatch != "" && (!exists || current.version() != w.opts.IfMatch) {
+		return fmt.Errorf("memory storage adapter: %s has changed: %w", w.key, ErrPreconditionFailed)
+	}
+
+	// Preconditions are not part of the stored object
+	options := w.opts
+	options.IfNoneMatch, options.IfMatch = false, ""
+	options.Metadata = maps.Clone(options.Metadata)
+
+	w.driver.objects[w.key] = memoryObject{data: w.buf.Bytes(), modifiedAt: time.Now(), options: options}
+	return nil
+}
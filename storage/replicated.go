@@ -0,0 +1,611 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReplicationPolicy decides when a write to a replicated storage succeeds
+type ReplicationPolicy int
+
+const (
+	// ReplicateAll requires the write to succeed on every backend
+	ReplicateAll ReplicationPolicy = iota
+
+	// ReplicateQuorum requires the write to succeed on a majority of
+	// backends
+	ReplicateQuorum
+
+	// ReplicatePrimaryAsync requires the write to succeed on the primary
+	// backend. Other backends are updated in the background by copying
+	// the object from the primary
+	ReplicatePrimaryAsync
+)
+
+func (p ReplicationPolicy) String() string {
+	switch p {
+	case ReplicateAll:
+		return "all"
+	case ReplicateQuorum:
+		return "quorum"
+	case ReplicatePrimaryAsync:
+		return "primary-async"
+	default:
+		return fmt.Sprintf("ReplicationPolicy(%d)", int(p))
+	}
+}
+
+var errReplicaWriteFailed = errors.New("replicated storage: replica write failed")
+
+// Time for which a failing backend is tried last when reading
+const defaultReplicaUnhealthyTimeout = 30 * time.Second
+
+// ReplicaBackend is a named backend of a replicated storage
+type ReplicaBackend struct {
+	Name    string
+	Storage StorageWriter
+}
+
+type ReplicatedStorageConfig struct {
+	// Backends to replicate to. The first backend is the primary
+	Backends []ReplicaBackend
+
+	// Defaults to ReplicateAll
+	Policy ReplicationPolicy
+
+	// Time for which a backend that failed is tried after the other
+	// backends when reading. Defaults to 30 seconds
+	UnhealthyTimeout time.Duration
+
+	// Called when a background replication of ReplicatePrimaryAsync fails.
+	// Such objects can be recovered with Repair
+	OnReplicationError func(backend, key string, err error)
+}
+
+// RepairOptions selects the objects checked by Repair
+type RepairOptions struct {
+	// Keys to check. When empty, objects with Prefix are listed from all
+	// backends, which then must implement ObjectStorage
+	Keys []string
+
+	Prefix string
+}
+
+// RepairResult summarizes a repair
+type RepairResult struct {
+	// Number of keys checked
+	Checked int
+
+	// Number of objects copied to backends missing them
+	Copied int
+}
+
+// ReplicatedStorage is a storage that writes objects to multiple backends
+type ReplicatedStorage interface {
+	ContextStorageWriter
+
+	// Repair copies objects missing from a backend from the first backend
+	// that has them. Repair continues on failures and returns them joined
+	Repair(ctx context.Context, opts RepairOptions) (*RepairResult, error)
+
+	// Wait blocks until pending background replications are complete
+	Wait()
+}
+
+type replicaBackend struct {
+	ReplicaBackend
+
+	m              sync.Mutex
+	unhealthyUntil time.Time
+}
+
+type replicatedStorage struct {
+	config   ReplicatedStorageConfig
+	backends []*replicaBackend
+	pending  sync.WaitGroup
+}
+
+// NewReplicatedStorage returns a storage that fans writes out to all
+// backends and reads from the first healthy backend that has the object
+func NewReplicatedStorage(config ReplicatedStorageConfig) (ReplicatedStorage, error) {
+	if len(config.Backends) == 0 {
+		return nil, fmt.Errorf("replicated storage: at least one backend is required")
+	}
+
+	switch config.Policy {
+	case ReplicateAll, ReplicateQuorum, ReplicatePrimaryAsync:
+	default:
+		return nil, fmt.Errorf("replicated storage: unknown policy %s", config.Policy)
+	}
+
+	if config.UnhealthyTimeout == 0 {
+		config.UnhealthyTimeout = defaultReplicaUnhealthyTimeout
+	}
+
+	backends := make([]*replicaBackend, 0, len(config.Backends))
+	for i, backend := range config.Backends {
+		if backend.Storage == nil {
+			return nil, fmt.Errorf("replicated storage: backend %d has no storage", i)
+		}
+
+		if backend.Name == "" {
+			backend.Name = fmt.Sprintf("backend-%d", i)
+		}
+
+		backends = append(backends, &replicaBackend{ReplicaBackend: backend})
+	}
+
+	return &replicatedStorage{config: config, backends: backends}, nil
+}
+
+func (s *replicatedStorage) Put(key string, reader io.Reader) error {
+	return s.PutContext(context.Background(), key, reader)
+}
+
+func (s *replicatedStorage) Get(key string) (io.ReadCloser, error) {
+	return s.GetContext(context.Background(), key)
+}
+
+func (s *replicatedStorage) Writer(key string) (io.WriteCloser, error) {
+	return s.WriterContext(context.Background(), key)
+}
+
+func (s *replicatedStorage) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	writer, err := s.writer(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, &contextReader{ctx: ctx, reader: reader}); err != nil {
+		writer.abort(err)
+		return fmt.Errorf("replicated storage: failed to write: %w", err)
+	}
+
+	return writer.Close()
+}
+
+// GetContext reads from healthy backends in order before falling back to
+// backends that failed recently. ErrNotFound is returned only when no
+// backend failed and none has the object
+func (s *replicatedStorage) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	var errs []error
+	for _, backend := range s.readOrder() {
+		reader, err := getContext(ctx, backend.Storage, key)
+		if err == nil {
+			return reader, nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			backend.markUnhealthy(s.config.UnhealthyTimeout)
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("replicated storage: %s: %w", key, ErrNotFound)
+	}
+
+	return nil, fmt.Errorf("replicated storage: failed to read: %w", errors.Join(errs...))
+}
+
+func (s *replicatedStorage) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	return s.writer(ctx, key)
+}
+
+func (s *replicatedStorage) writer(ctx context.Context, key string) (*replicatedWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	backends := s.backends
+	if s.config.Policy == ReplicatePrimaryAsync {
+		backends = backends[:1]
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &replicatedWriter{storage: s, key: key, ctx: ctx, cancel: cancel}
+
+	for _, backend := range backends {
+		replica := &replicaWriter{backend: backend}
+		w.replicas = append(w.replicas, replica)
+
+		// Cancelled to abort the write of this backend alone when copying
+		// to it fails
+		replicaCtx, replicaCancel := context.WithCancel(ctx)
+
+		writer, err := writerContext(replicaCtx, backend.Storage, key)
+		if err != nil {
+			replicaCancel()
+
+			replica.err = err
+			replica.finished = true
+
+			continue
+		}
+
+		pr, pw := io.Pipe()
+		replica.writer = pw
+		replica.done = make(chan error, 1)
+
+		go func() {
+			defer replicaCancel()
+
+			_, err := io.Copy(writer, pr)
+			if err == nil {
+				err = writer.Close()
+			} else {
+				replicaCancel()
+				abortWriter(backend.Storage, writer, err)
+			}
+
+			// Unblocks writes when the backend stopped reading early
+			_ = pr.CloseWithError(errReplicaWriteFailed)
+			replica.done <- err
+		}()
+	}
+
+	if err := w.check(); err != nil {
+		w.abort(err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *replicatedStorage) Wait() {
+	s.pending.Wait()
+}
+
+// Repair checks every key on every backend. Listing keeps all keys in
+// memory, so large buckets should be repaired one prefix at a time
+func (s *replicatedStorage) Repair(ctx context.Context, opts RepairOptions) (*RepairResult, error) {
+	keys := opts.Keys
+	if len(keys) == 0 {
+		var err error
+		if keys, err = s.listKeys(ctx, opts.Prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &RepairResult{}
+
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		result.Checked++
+
+		copied, err := s.repairKey(ctx, key)
+		result.Copied += copied
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("replicated storage: repair failed: %w", errors.Join(errs...))
+	}
+
+	return result, nil
+}
+
+// repairKey copies a key from the first backend that has it to the
+// backends that do not
+func (s *replicatedStorage) repairKey(ctx context.Context, key string) (int, error) {
+	var source *replicaBackend
+	var missing []*replicaBackend
+
+	for _, backend := range s.backends {
+		exists, err := objectExists(ctx, backend.Storage, key)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %s: %w", backend.Name, key, err)
+		}
+
+		switch {
+		case !exists:
+			missing = append(missing, backend)
+		case source == nil:
+			source = backend
+		}
+	}
+
+	if source == nil {
+		return 0, nil
+	}
+
+	copied := 0
+	for _, target := range missing {
+		if err := copyObject(ctx, source, target, key); err != nil {
+			return copied, err
+		}
+
+		copied++
+	}
+
+	return copied, nil
+}
+
+// listKeys returns the sorted union of keys with prefix in all backends
+func (s *replicatedStorage) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, backend := range s.backends {
+		objects, ok := backend.Storage.(ObjectStorage)
+		if !ok {
+			return nil, fmt.Errorf("replicated storage: %s cannot list objects, keys are required", backend.Name)
+		}
+
+		opts := ListOptions{Prefix: prefix}
+		for {
+			res, err := objects.List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("replicated storage: %s: failed to list objects: %w", backend.Name, err)
+			}
+
+			for _, object := range res.Objects {
+				seen[object.Key] = true
+			}
+
+			if res.NextPageToken == "" {
+				break
+			}
+
+			opts.PageToken = res.NextPageToken
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// replicate copies an object from the primary to the other backends in
+// the background
+func (s *replicatedStorage) replicate(key string) {
+	primary := s.backends[0]
+	for _, target := range s.backends[1:] {
+		s.pending.Add(1)
+
+		go func() {
+			defer s.pending.Done()
+
+			err := copyObject(context.Background(), primary, target, key)
+			if err != nil && s.config.OnReplicationError != nil {
+				s.config.OnReplicationError(target.Name, key, err)
+			}
+		}()
+	}
+}
+
+// readOrder returns healthy backends followed by unhealthy backends
+func (s *replicatedStorage) readOrder() []*replicaBackend {
+	var healthy, unhealthy []*replicaBackend
+	for _, backend := range s.backends {
+		if backend.healthy() {
+			healthy = append(healthy, backend)
+		} else {
+			unhealthy = append(unhealthy, backend)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// required returns the number of successful backend writes required by
+// the policy
+func (s *replicatedStorage) required(replicas int) int {
+	if s.config.Policy == ReplicateQuorum {
+		return replicas/2 + 1
+	}
+
+	return replicas
+}
+
+func (b *replicaBackend) healthy() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	return time.Now().After(b.unhealthyUntil)
+}
+
+func (b *replicaBackend) markUnhealthy(timeout time.Duration) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.unhealthyUntil = time.Now().Add(timeout)
+}
+
+// copyObject streams an object from source to target
+func copyObject(ctx context.Context, source, target *replicaBackend, key string) error {
+	reader, err := getContext(ctx, source.Storage, key)
+	if err != nil {
+		return fmt.Errorf("%s: failed to read %s: %w", source.Name, key, err)
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	if err := putContext(ctx, target.Storage, key, reader); err != nil {
+		return fmt.Errorf("%s: failed to write %s: %w", target.Name, key, err)
+	}
+
+	return nil
+}
+
+// objectExists uses Exists when supported by the storage and falls back
+// to opening the object
+func objectExists(ctx context.Context, s Storage, key string) (bool, error) {
+	if objects, ok := s.(ObjectStorage); ok {
+		return objects.Exists(ctx, key)
+	}
+
+	reader, err := getContext(ctx, s, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, reader.Close()
+}
+
+type replicaWriter struct {
+	backend  *replicaBackend
+	writer   *io.PipeWriter
+	done     chan error
+	finished bool
+	err      error
+}
+
+// wait returns the result of the backend write once it is complete
+func (r *replicaWriter) wait() error {
+	if !r.finished {
+		r.finished = true
+		if err := <-r.done; err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+
+	return r.err
+}
+
+// replicatedWriter copies writes to a pipe per backend. Backends that fail
+// are dropped while enough backends remain to satisfy the policy
+type replicatedWriter struct {
+	storage  *replicatedStorage
+	key      string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	replicas []*replicaWriter
+	closed   bool
+}
+
+func (w *replicatedWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("replicated storage: writer is closed")
+	}
+
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, replica := range w.replicas {
+		if replica.finished {
+			continue
+		}
+
+		// The pipe fails when the backend stopped reading, its own error
+		// is more useful
+		if _, err := replica.writer.Write(p); err != nil && replica.wait() == nil {
+			replica.err = err
+		}
+	}
+
+	if err := w.check(); err != nil {
+		w.abort(err)
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *replicatedWriter) Close() error {
+	if w.closed {
+		return fmt.Errorf("replicated storage: writer is closed")
+	}
+
+	w.closed = true
+	defer w.cancel()
+
+	// Backends observe cancellation of ctx while draining the pipe and
+	// abort their writes
+	if err := w.ctx.Err(); err != nil {
+		w.abort(err)
+		return err
+	}
+
+	for _, replica := range w.replicas {
+		if !replica.finished {
+			_ = replica.writer.Close()
+		}
+	}
+
+	for _, replica := range w.replicas {
+		if replica.wait() != nil {
+			replica.backend.markUnhealthy(w.storage.config.UnhealthyTimeout)
+		}
+	}
+
+	if err := w.check(); err != nil {
+		return err
+	}
+
+	if w.storage.config.Policy == ReplicatePrimaryAsync {
+		w.storage.replicate(w.key)
+	}
+
+	return nil
+}
+
+// abort cancels the write on all backends and waits for them to stop
+func (w *replicatedWriter) abort(err error) {
+	w.closed = true
+	w.cancel()
+
+	for _, replica := range w.replicas {
+		if !replica.finished {
+			_ = replica.writer.CloseWithError(err)
+		}
+	}
+
+	for _, replica := range w.replicas {
+		_ = replica.wait()
+	}
+}
+
+// abortWriter stops a write whose ctx is cancelled without creating the
+// object. Writers of storages that are not context aware commit on Close,
+// so they are left unclosed unless they can be closed with an error
+func abortWriter(storage StorageWriter, writer io.WriteCloser, err error) {
+	if _, ok := storage.(ContextStorageWriter); ok {
+		_ = writer.Close()
+		return
+	}
+
+	if w, ok := writer.(interface{ CloseWithError(error) error }); ok {
+		_ = w.CloseWithError(err)
+	}
+}
+
+// check fails when too many backends failed to satisfy the policy
+func (w *replicatedWriter) check() error {
+	var errs []error
+	for _, replica := range w.replicas {
+		if replica.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", replica.backend.Name, replica.err))
+		}
+	}
+
+	if len(w.replicas)-len(errs) >= w.storage.required(len(w.replicas)) {
+		return nil
+	}
+
+	return fmt.Errorf("replicated storage: %s policy not satisfied: %w",
+		w.storage.config.Policy, errors.Join(errs...))
+}
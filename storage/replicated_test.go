@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackendDown = errors.New("backend down")
+
+// failingStorage fails every operation while down is set
+type failingStorage struct {
+	StorageWriter
+
+	down  atomic.Bool
+	reads atomic.Int32
+}
+
+func newFailingStorage(down bool) *failingStorage {
+	s := &failingStorage{StorageWriter: NewMemoryStorageDriver()}
+	s.down.Store(down)
+
+	return s
+}
+
+func (s *failingStorage) Put(key string, reader io.Reader) error {
+	if s.down.Load() {
+		return errBackendDown
+	}
+
+	return s.StorageWriter.Put(key, reader)
+}
+
+func (s *failingStorage) Get(key string) (io.ReadCloser, error) {
+	s.reads.Add(1)
+	if s.down.Load() {
+		return nil, errBackendDown
+	}
+
+	return s.StorageWriter.Get(key)
+}
+
+func (s *failingStorage) Writer(key string) (io.WriteCloser, error) {
+	if s.down.Load() {
+		return nil, errBackendDown
+	}
+
+	return s.StorageWriter.Writer(key)
+}
+
+func TestReplicatedStoragePolicies(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  ReplicationPolicy
+		down    []bool
+		success bool
+	}{
+		{"all succeed", ReplicateAll, []bool{false, false, false}, true},
+		{"all with a failed backend", ReplicateAll, []bool{false, false, true}, false},
+		{"quorum with a failed backend", ReplicateQuorum, []bool{false, true, false}, true},
+		{"quorum with two failed backends", ReplicateQuorum, []bool{true, false, true}, false},
+		{"primary async with a failed replica", ReplicatePrimaryAsync, []bool{false, true, true}, true},
+		{"primary async with a failed primary", ReplicatePrimaryAsync, []bool{true, false, false}, false},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var backends []ReplicaBackend
+			var stores []StorageWriter
+
+			for _, down := range test.down {
+				backend := ReplicaBackend{Storage: NewMemoryStorageDriver()}
+				stores = append(stores, backend.Storage)
+
+				if down {
+					failing := newFailingStorage(true)
+					backend.Storage, stores[len(stores)-1] = failing, failing.StorageWriter
+				}
+
+				backends = append(backends, backend)
+			}
+
+			s, err := NewReplicatedStorage(ReplicatedStorageConfig{Backends: backends, Policy: test.policy})
+			require.NoError(t, err)
+
+			err = s.Put("artifact.tgz", strings.NewReader("artifact"))
+			if test.success {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errBackendDown)
+			}
+
+			s.Wait()
+
+			// Failed writes are aborted on every backend
+			for i, down := range test.down {
+				_, err := stores[i].Get("artifact.tgz")
+				if !down && test.success {
+					assert.NoError(t, err, "backend %d", i)
+				} else {
+					assert.ErrorIs(t, err, ErrNotFound, "backend %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestReplicatedStorageAsyncReplicationErrors(t *testing.T) {
+	replica := newFailingStorage(true)
+
+	var m sync.Mutex
+	var failed []string
+
+	s, err := NewReplicatedStorage(ReplicatedStorageConfig{
+		Backends: []ReplicaBackend{
+			{Name: "gcs", Storage: NewMemoryStorageDriver()},
+			{Name: "r2", Storage: replica},
+		},
+		Policy: ReplicatePrimaryAsync,
+		OnReplicationError: func(backend, key string, err error) {
+			m.Lock()
+			defer m.Unlock()
+
+			assert.ErrorIs(t, err, errBackendDown)
+			failed = append(failed, backend+"/"+key)
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put("a.json", strings.NewReader("a")))
+	s.Wait()
+
+	assert.Equal(t, []string{"r2/a.json"}, failed)
+
+	// The replica catches up once it recovers
+	replica.down.Store(false)
+
+	res, err := s.Repair(context.Background(), RepairOptions{Keys: []string{"a.json"}})
+	require.NoError(t, err)
+	assert.Equal(t, &RepairResult{Checked: 1, Copied: 1}, res)
+
+	content, err := readAllFrom(t, replica, "a.json")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(content))
+}
+
+func TestReplicatedStorageReadsFromHealthyBackend(t *testing.T) {
+	primary := newFailingStorage(false)
+	secondary := newFailingStorage(false)
+
+	s, err := NewReplicatedStorage(ReplicatedStorageConfig{
+		Backends: []ReplicaBackend{{Storage: primary}, {Storage: secondary}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Put("report.json", strings.NewReader("report")))
+
+	primary.down.Store(true)
+
+	for range 3 {
+		content, err := readAllFrom(t, s, "report.json")
+		require.NoError(t, err)
+		assert.Equal(t, "report", string(content))
+	}
+
+	// The failed primary is tried last while it is unhealthy
+	assert.Equal(t, int32(1), primary.reads.Load())
+
+	_, err = s.Get("missing.json")
+	assert.ErrorIs(t, err, errBackendDown)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	primary.down.Store(false)
+
+	_, err = s.Get("missing.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReplicatedStorageFailedWriteIsNotCommitted(t *testing.T) {
+	// Writers of storages that are not context aware commit on Close
+	stores := []StorageWriter{newFailingStorage(false), newFailingStorage(false), NewMemoryStorageDriver()}
+
+	var backends []ReplicaBackend
+	for _, store := range stores {
+		backends = append(backends, ReplicaBackend{Storage: store})
+	}
+
+	s, err := NewReplicatedStorage(ReplicatedStorageConfig{Backends: backends, Policy: ReplicateQuorum})
+	require.NoError(t, err)
+
+	err = s.Put("report.json", io.MultiReader(strings.NewReader("partial"), failingReader{}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	for _, store := range stores {
+		_, err := store.Get("report.json")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestReplicatedStorageRepair(t *testing.T) {
+	gcs := NewMemoryStorageDriver()
+	r2 := NewMemoryStorageDriver()
+
+	require.NoError(t, gcs.Put("scans/a.json", strings.NewReader("a")))
+	require.NoError(t, gcs.Put("scans/b.json", strings.NewReader("b")))
+	require.NoError(t, r2.Put("scans/b.json", strings.NewReader("b")))
+	require.NoError(t, r2.Put("scans/c.json", strings.NewReader("c")))
+	require.NoError(t, r2.Put("other/d.json", strings.NewReader("d")))
+
+	s, err := NewReplicatedStorage(ReplicatedStorageConfig{
+		Backends: []ReplicaBackend{{Name: "gcs", Storage: gcs}, {Name: "r2", Storage: r2}},
+	})
+	require.NoError(t, err)
+
+	res, err := s.Repair(context.Background(), RepairOptions{Prefix: "scans/"})
+	require.NoError(t, err)
+	assert.Equal(t, &RepairResult{Checked: 3, Copied: 2}, res)
+
+	for _, backend := range []ObjectStorage{gcs, r2} {
+		for _, key := range []string{"scans/a.json", "scans/b.json", "scans/c.json"} {
+			exists, err := backend.Exists(context.Background(), key)
+			require.NoError(t, err)
+			assert.True(t, exists, key)
+		}
+	}
+
+	exists, err := gcs.Exists(context.Background(), "other/d.json")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Listing requires every backend to support it
+	plain, err := NewReplicatedStorage(ReplicatedStorageConfig{
+		Backends: []ReplicaBackend{{Storage: gcs}, {Storage: plainStorageWriter{r2}}},
+	})
+	require.NoError(t, err)
+
+	_, err = plain.Repair(context.Background(), RepairOptions{})
+	assert.Error(t, err)
+
+	res, err = plain.Repair(context.Background(), RepairOptions{Keys: []string{"other/d.json"}})
+	require.NoError(t, err)
+	assert.Equal(t, &RepairResult{Checked: 1, Copied: 1}, res)
+}
+
+func TestNewReplicatedStorageValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		config ReplicatedStorageConfig
+	}{
+		{"no backends", ReplicatedStorageConfig{}},
+		{"nil storage", ReplicatedStorageConfig{Backends: []ReplicaBackend{{Name: "gcs"}}}},
+		{"unknown policy", ReplicatedStorageConfig{
+			Backends: []ReplicaBackend{{Storage: NewMemoryStorageDriver()}},
+			Policy:   ReplicationPolicy(10),
+		}},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewReplicatedStorage(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
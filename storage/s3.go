@@ -2,14 +2,21 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
 // S3StorageDriverConfig is the config for the S3 storage driver.
@@ -28,10 +35,14 @@ type S3StorageDriverConfig struct {
 type s3StorageDriver struct {
 	client     *s3.Client
 	transferer *transfermanager.Client
+	presigner  *s3.PresignClient
 	config     S3StorageDriverConfig
-	ctx        context.Context
 }
 
+// Time allowed to abort a multipart upload after the upload context is
+// cancelled
+const s3AbortTimeout = 30 * time.Second
+
 type s3StorageDriverOpts func(*s3StorageDriver)
 
 // WithS3Client injects a pre-built *s3.Client. Used for tests and for
@@ -43,7 +54,12 @@ func WithS3Client(client *s3.Client) s3StorageDriverOpts {
 	}
 }
 
-var _ StorageWriter = (*s3StorageDriver)(nil)
+var (
+	_ ObjectStorage        = (*s3StorageDriver)(nil)
+	_ OptionsStorageWriter = (*s3StorageDriver)(nil)
+	_ SignedURLStorage     = (*s3StorageDriver)(nil)
+	_ encodedReader        = (*s3StorageDriver)(nil)
+)
 
 // NewS3StorageDriver constructs an S3-backed StorageWriter.
 //
@@ -65,7 +81,6 @@ func NewS3StorageDriver(config S3StorageDriverConfig,
 
 	d := &s3StorageDriver{
 		config: config,
-		ctx:    context.Background(),
 	}
 
 	for _, opt := range opts {
@@ -97,7 +112,7 @@ func NewS3StorageDriver(config S3StorageDriverConfig,
 		//   - On EKS, prefer IRSA / Pod Identity over the node's IMDS
 		//     role: IRSA scopes credentials to the pod; node IMDS grants
 		//     whatever the underlying EC2 role has.
-		cfg, err := awsconfig.LoadDefaultConfig(d.ctx)
+		cfg, err := awsconfig.LoadDefaultConfig(context.Background())
 		if err != nil {
 			return nil, fmt.Errorf("s3 storage adapter: failed to load AWS config: %w", err)
 		}
@@ -105,71 +120,116 @@ func NewS3StorageDriver(config S3StorageDriverConfig,
 	}
 
 	d.transferer = transfermanager.New(d.client)
+	d.presigner = s3.NewPresignClient(d.client)
 
 	return d, nil
 }
 
 func (d *s3StorageDriver) Put(key string, reader io.Reader) error {
+	return d.PutContext(context.Background(), key, reader)
+}
+
+func (d *s3StorageDriver) Get(key string) (io.ReadCloser, error) {
+	return d.GetContext(context.Background(), key)
+}
+
+func (d *s3StorageDriver) Writer(key string) (io.WriteCloser, error) {
+	return d.WriterContext(context.Background(), key)
+}
+
+func (d *s3StorageDriver) PutContext(ctx context.Context, key string, reader io.Reader) error {
+	return d.PutWithOptions(ctx, key, reader, PutOptions{})
+}
+
+// PutWithOptions uploads with S3 conditional writes. The version used with
+// IfMatch is the ETag of the object
+func (d *s3StorageDriver) PutWithOptions(ctx context.Context, key string, reader io.Reader, opts PutOptions) error {
 	keyName, err := d.prefix(key)
 	if err != nil {
 		return fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
 	}
 
-	_, err = d.transferer.UploadObject(d.ctx, &transfermanager.UploadObjectInput{
-		Bucket: aws.String(d.config.BucketName),
-		Key:    aws.String(keyName),
-		Body:   reader,
-	})
-	if err != nil {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	if err := d.upload(ctx, keyName, reader, opts); err != nil {
 		return fmt.Errorf("s3 storage adapter: failed to upload object: %w", err)
 	}
 
 	return nil
 }
 
-func (d *s3StorageDriver) Get(key string) (io.ReadCloser, error) {
+func (d *s3StorageDriver) GetContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := d.getObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Body, nil
+}
+
+func (d *s3StorageDriver) getEncoded(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	out, err := d.getObject(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return out.Body, aws.ToString(out.ContentEncoding), nil
+}
+
+func (d *s3StorageDriver) getObject(ctx context.Context, key string) (*s3.GetObjectOutput, error) {
 	keyName, err := d.prefix(key)
 	if err != nil {
 		return nil, fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
 	}
 
-	out, err := d.client.GetObject(d.ctx, &s3.GetObjectInput{
+	out, err := d.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(d.config.BucketName),
 		Key:    aws.String(keyName),
 	})
 	if err != nil {
+		if s3IsNotFound(err) {
+			return nil, fmt.Errorf("s3 storage adapter: %s: %w", keyName, ErrNotFound)
+		}
+
 		return nil, fmt.Errorf("s3 storage adapter: failed to get object: %w", err)
 	}
 
-	return out.Body, nil
+	return out, nil
 }
 
-// Writer bridges io.WriteCloser semantics onto S3. Neither s3.PutObject
-// nor transfermanager.UploadObject expose an io.Writer — both take an
-// io.Reader body. We pipe writes from the returned WriteCloser into a
-// goroutine that feeds UploadObject. Memory is bounded by the
+// WriterContext bridges io.WriteCloser semantics onto S3. Neither
+// s3.PutObject nor transfermanager.UploadObject expose an io.Writer — both
+// take an io.Reader body. We pipe writes from the returned WriteCloser into
+// a goroutine that feeds UploadObject. Memory is bounded by the
 // transfermanager's part size × concurrency, independent of object size.
 // Reference for transfermanager config and behavior:
 //
 //	https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager
 //
 // The io.Pipe + goroutine pattern follows the same shape AWS uses for
-// arbitrary-sized streams in aws-sdk-go-v2 examples.
-func (d *s3StorageDriver) Writer(key string) (io.WriteCloser, error) {
+// arbitrary-sized streams in aws-sdk-go-v2 examples. Cancelling ctx fails
+// pending writes and aborts the upload.
+func (d *s3StorageDriver) WriterContext(ctx context.Context, key string) (io.WriteCloser, error) {
+	return d.WriterWithOptions(ctx, key, PutOptions{})
+}
+
+func (d *s3StorageDriver) WriterWithOptions(ctx context.Context, key string, opts PutOptions) (io.WriteCloser, error) {
 	keyName, err := d.prefix(key)
 	if err != nil {
 		return nil, fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
 	}
 
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	pr, pw := io.Pipe()
 	errCh := make(chan error, 1)
 
 	go func() {
-		_, err := d.transferer.UploadObject(d.ctx, &transfermanager.UploadObjectInput{
-			Bucket: aws.String(d.config.BucketName),
-			Key:    aws.String(keyName),
-			Body:   pr,
-		})
+		err := d.upload(ctx, keyName, pr, opts)
 		// Unblock any pending Write on the pipe if the upload errored
 		// mid-stream; the caller's next Write will observe err.
 		pr.CloseWithError(err)
@@ -179,6 +239,62 @@ func (d *s3StorageDriver) Writer(key string) (io.WriteCloser, error) {
 	return &s3Writer{pw: pw, errCh: errCh}, nil
 }
 
+// upload streams body to keyName. The transfermanager aborts a failed
+// multipart upload using the upload context, which no longer works once
+// that context is cancelled. Abort it here with a detached context so that
+// cancelled uploads do not leave orphaned parts in the bucket
+func (d *s3StorageDriver) upload(ctx context.Context, keyName string, body io.Reader, opts PutOptions) error {
+	input := &transfermanager.UploadObjectInput{
+		Bucket:   aws.String(d.config.BucketName),
+		Key:      aws.String(keyName),
+		Body:     body,
+		Metadata: opts.Metadata,
+	}
+
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+
+	if opts.ContentEncoding != "" {
+		input.ContentEncoding = aws.String(opts.ContentEncoding)
+	}
+
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+
+	if opts.IfNoneMatch {
+		input.IfNoneMatch = aws.String("*")
+	}
+
+	if opts.IfMatch != "" {
+		input.IfMatch = aws.String(opts.IfMatch)
+	}
+
+	_, err := d.transferer.UploadObject(ctx, input)
+	if s3IsPreconditionFailed(err) {
+		return fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+
+	var multipartErr transfermanager.MultipartUploadError
+	if err != nil && ctx.Err() != nil && errors.As(err, &multipartErr) && multipartErr.UploadID() != "" {
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s3AbortTimeout)
+		defer cancel()
+
+		_, abortErr := d.client.AbortMultipartUpload(abortCtx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(d.config.BucketName),
+			Key:      aws.String(keyName),
+			UploadId: aws.String(multipartErr.UploadID()),
+		})
+		var noSuchUpload *types.NoSuchUpload
+		if abortErr != nil && !errors.As(abortErr, &noSuchUpload) {
+			err = errors.Join(err, fmt.Errorf("failed to abort multipart upload: %w", abortErr))
+		}
+	}
+
+	return err
+}
+
 type s3Writer struct {
 	pw    *io.PipeWriter
 	errCh chan error
@@ -204,6 +320,233 @@ func (w *s3Writer) Close() error {
 	return nil
 }
 
+// List uses ListObjectsV2. Content type is not returned by S3 when listing
+// and is left empty
+func (d *s3StorageDriver) List(ctx context.Context, opts ListOptions) (*ListResult, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(d.config.BucketName),
+		Prefix:  aws.String(strings.TrimLeft(opts.Prefix, "/")),
+		MaxKeys: aws.Int32(int32(listPageSize(opts))),
+	}
+
+	if opts.PageToken != "" {
+		input.ContinuationToken = aws.String(opts.PageToken)
+	}
+
+	out, err := d.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to list objects: %w", err)
+	}
+
+	result := &ListResult{}
+	for _, object := range out.Contents {
+		result.Objects = append(result.Objects, ObjectInfo{
+			Key:               aws.ToString(object.Key),
+			Size:              aws.ToInt64(object.Size),
+			ModifiedAt:        aws.ToTime(object.LastModified),
+			Checksum:          strings.Trim(aws.ToString(object.ETag), `"`),
+			ChecksumAlgorithm: "etag",
+			Version:           aws.ToString(object.ETag),
+		})
+	}
+
+	if aws.ToBool(out.IsTruncated) {
+		result.NextPageToken = aws.ToString(out.NextContinuationToken)
+	}
+
+	return result, nil
+}
+
+// Stat uses HeadObject. The checksum is the ETag which is the MD5 of the
+// content only for objects not uploaded in multiple parts
+func (d *s3StorageDriver) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
+	}
+
+	out, err := d.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(d.config.BucketName),
+		Key:    aws.String(keyName),
+	})
+	if err != nil {
+		if s3IsNotFound(err) {
+			return nil, fmt.Errorf("s3 storage adapter: %s: %w", keyName, ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("s3 storage adapter: failed to head object: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:               keyName,
+		Size:              aws.ToInt64(out.ContentLength),
+		ModifiedAt:        aws.ToTime(out.LastModified),
+		ContentType:       aws.ToString(out.ContentType),
+		ContentEncoding:   aws.ToString(out.ContentEncoding),
+		CacheControl:      aws.ToString(out.CacheControl),
+		Metadata:          out.Metadata,
+		Checksum:          strings.Trim(aws.ToString(out.ETag), `"`),
+		ChecksumAlgorithm: "etag",
+		Version:           aws.ToString(out.ETag),
+	}, nil
+}
+
+func (d *s3StorageDriver) Delete(ctx context.Context, key string) error {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
+	}
+
+	_, err = d.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.config.BucketName),
+		Key:    aws.String(keyName),
+	})
+	if err != nil && !s3IsNotFound(err) {
+		return fmt.Errorf("s3 storage adapter: failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+func (d *s3StorageDriver) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := d.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SignedGetURL presigns GetObject. The content type, when set, overrides
+// the Content-Type of the response
+func (d *s3StorageDriver) SignedGetURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
+	}
+
+	expiry, err := signedURLExpiry(opts)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: %w", err)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(d.config.BucketName),
+		Key:    aws.String(keyName),
+	}
+
+	if opts.ContentType != "" {
+		input.ResponseContentType = aws.String(opts.ContentType)
+	}
+
+	expiresAt := time.Now().Add(expiry)
+	req, err := d.presigner.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to presign get object: %w", err)
+	}
+
+	return s3SignedURL(req, expiresAt), nil
+}
+
+// SignedPutURL presigns PutObject. The content type, when set, is signed
+// and the upload must send the same Content-Type header
+func (d *s3StorageDriver) SignedPutURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error) {
+	keyName, err := d.prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to prefix key: %w", err)
+	}
+
+	expiry, err := signedURLExpiry(opts)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(d.config.BucketName),
+		Key:    aws.String(keyName),
+	}
+
+	presignOpts := []func(*s3.PresignOptions){s3.WithPresignExpires(expiry)}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+		presignOpts = append(presignOpts, s3SignContentType(opts.ContentType))
+	}
+
+	expiresAt := time.Now().Add(expiry)
+	req, err := d.presigner.PresignPutObject(ctx, input, presignOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage adapter: failed to presign put object: %w", err)
+	}
+
+	return s3SignedURL(req, expiresAt), nil
+}
+
+// s3SignContentType restores the Content-Type header that the SDK removes
+// from presigned requests without a body, so that it is signed
+func s3SignContentType(contentType string) func(*s3.PresignOptions) {
+	restore := middleware.BuildMiddlewareFunc("SignContentType", func(ctx context.Context,
+		in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		return next.HandleBuild(ctx, in)
+	})
+
+	return func(o *s3.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+				return stack.Build.Add(restore, middleware.After)
+			})
+		})
+	}
+}
+
+// s3SignedURL drops the Host header from the signed headers since HTTP
+// clients set it from the URL
+func s3SignedURL(req *v4.PresignedHTTPRequest, expiresAt time.Time) *SignedURL {
+	headers := req.SignedHeader.Clone()
+	headers.Del("Host")
+
+	return &SignedURL{
+		URL:       req.URL,
+		Method:    req.Method,
+		Headers:   headers,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// s3IsNotFound reports whether err is a missing object error. HeadObject
+// has no response body and reports NotFound while GetObject reports
+// NoSuchKey
+func s3IsNotFound(err error) bool {
+	var notFound *types.NotFound
+	var noSuchKey *types.NoSuchKey
+
+	return errors.As(err, &notFound) || errors.As(err, &noSuchKey)
+}
+
+// s3IsPreconditionFailed reports whether a conditional write was rejected.
+// S3 returns ConditionalRequestConflict when a concurrent conditional
+// write is in progress
+func s3IsPreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.ErrorCode() {
+	case "PreconditionFailed", "ConditionalRequestConflict":
+		return true
+	default:
+		return false
+	}
+}
+
 func (d *s3StorageDriver) prefix(key string) (string, error) {
 	key = strings.TrimLeft(key, "/")
 	key = strings.TrimRight(key, "/")
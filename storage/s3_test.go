@@ -6,12 +6,16 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/oklog/ulid/v2"
 	"github.com/stretchr/testify/assert"
@@ -69,6 +73,62 @@ func TestNewS3StorageDriverRequiresBucket(t *testing.T) {
 	assert.ErrorContains(t, err, "bucket name is required")
 }
 
+func TestS3StorageDriverSignedURL(t *testing.T) {
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("https://s3.example.com"),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+
+	driver, err := NewS3StorageDriver(S3StorageDriverConfig{BucketName: "reports"}, WithS3Client(client))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("get", func(t *testing.T) {
+		signed, err := driver.SignedGetURL(ctx, "/scans/report.json", SignedURLOptions{
+			Expiry:      time.Hour,
+			ContentType: "application/json",
+		})
+		require.NoError(t, err)
+
+		u, err := url.Parse(signed.URL)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.MethodGet, signed.Method)
+		assert.Equal(t, "/reports/scans/report.json", u.Path)
+		assert.Equal(t, "3600", u.Query().Get("X-Amz-Expires"))
+		assert.Equal(t, "application/json", u.Query().Get("response-content-type"))
+		assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+		assert.Empty(t, signed.Headers.Get("Host"))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), signed.ExpiresAt, time.Minute)
+	})
+
+	t.Run("put", func(t *testing.T) {
+		signed, err := driver.SignedPutURL(ctx, "uploads/sbom.json", SignedURLOptions{
+			ContentType: "application/json",
+		})
+		require.NoError(t, err)
+
+		u, err := url.Parse(signed.URL)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.MethodPut, signed.Method)
+		assert.Equal(t, "900", u.Query().Get("X-Amz-Expires"))
+		assert.Contains(t, u.Query().Get("X-Amz-SignedHeaders"), "content-type")
+		assert.Equal(t, "application/json", signed.Headers.Get("Content-Type"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := driver.SignedGetURL(ctx, "report.json", SignedURLOptions{Expiry: 8 * 24 * time.Hour})
+		assert.Error(t, err)
+
+		_, err = driver.SignedPutURL(ctx, "/", SignedURLOptions{})
+		assert.Error(t, err)
+	})
+}
+
 // TestS3StorageDriver_Integration exercises Put/Get/Writer against a real
 // S3-compatible endpoint (MinIO, LocalStack, real S3). Skipped unless
 // SAFEDEP_S3_INTEGRATION_TEST=1.
@@ -209,6 +269,78 @@ func TestS3StorageDriver_Integration(t *testing.T) {
 		assert.Equal(t, "second", string(got))
 	})
 
+	t.Run("List, Stat, Exists and Delete", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		prefix := runPrefix + "/objects/"
+		for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+			key := prefix + name
+			t.Cleanup(func() { deleteKey(t, driver, key) })
+			require.NoError(t, driver.Put(key, strings.NewReader(name)))
+		}
+
+		var keys []string
+		opts := ListOptions{Prefix: prefix, PageSize: 2}
+		for {
+			res, err := driver.List(ctx, opts)
+			require.NoError(t, err)
+
+			for _, object := range res.Objects {
+				keys = append(keys, object.Key)
+			}
+
+			if res.NextPageToken == "" {
+				break
+			}
+
+			opts.PageToken = res.NextPageToken
+		}
+
+		assert.Equal(t, []string{prefix + "a.txt", prefix + "b.txt", prefix + "c.txt"}, keys)
+
+		info, err := driver.Stat(ctx, prefix+"a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("a.txt")), info.Size)
+		assert.NotEmpty(t, info.Checksum)
+
+		_, err = driver.Stat(ctx, prefix+"missing.txt")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, driver.Delete(ctx, prefix+"a.txt"))
+		require.NoError(t, driver.Delete(ctx, prefix+"a.txt"))
+
+		exists, err := driver.Exists(ctx, prefix+"a.txt")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		exists, err = driver.Exists(ctx, prefix+"b.txt")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("cancelled writer does not create the object", func(t *testing.T) {
+		t.Parallel()
+
+		key := runPrefix + "/cancelled.bin"
+		t.Cleanup(func() { deleteKey(t, driver, key) })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := driver.WriterContext(ctx, key)
+		require.NoError(t, err)
+
+		// Large enough to start a multipart upload before cancelling
+		_, err = w.Write(randomBytes(t, 6<<20))
+		require.NoError(t, err)
+
+		cancel()
+		assert.Error(t, w.Close())
+
+		exists, err := driver.Exists(context.Background(), key)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
 	t.Run("prefix-only keys are rejected", func(t *testing.T) {
 		t.Parallel()
 		assert.Error(t, driver.Put("///", strings.NewReader("x")))
@@ -245,21 +377,11 @@ func randomBytes(t *testing.T, n int) []byte {
 	return buf
 }
 
-// deleteKey removes a test object. Uses driver internals because our
-// Storage interface deliberately has no Delete — integration tests clean
-// up their own objects to keep the bucket tidy across runs.
+// deleteKey removes a test object so that integration runs keep the
+// bucket tidy
 func deleteKey(t *testing.T, driver *s3StorageDriver, key string) {
 	t.Helper()
-	keyName, err := driver.prefix(key)
-	if err != nil {
-		t.Logf("cleanup: failed to prefix key %q: %v", key, err)
-		return
-	}
-	_, err = driver.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
-		Bucket: aws.String(driver.config.BucketName),
-		Key:    aws.String(keyName),
-	})
-	if err != nil {
-		t.Logf("cleanup: failed to delete %q: %v", keyName, err)
+	if err := driver.Delete(context.Background(), key); err != nil {
+		t.Logf("cleanup: failed to delete %q: %v", key, err)
 	}
 }
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrUnsupported is returned by drivers for optional operations that the
+// backend cannot support
+var ErrUnsupported = errors.New("storage: operation not supported")
+
+const (
+	defaultSignedURLExpiry = 15 * time.Minute
+
+	// Longest expiry allowed by S3 SigV4 and GCS V4 signing
+	maxSignedURLExpiry = 7 * 24 * time.Hour
+)
+
+// SignedURLOptions constrains the use of a signed URL
+type SignedURLOptions struct {
+	// Duration for which the URL is valid. Defaults to 15 minutes and
+	// must be at most 7 days
+	Expiry time.Duration
+
+	// Content type of the object. Uploads must send it as the Content-Type
+	// header and downloads are served with it. Optional
+	ContentType string
+}
+
+// SignedURL grants time limited access to a single object without
+// credentials
+type SignedURL struct {
+	URL    string
+	Method string
+
+	// Headers that the client must send with the request
+	Headers http.Header
+
+	ExpiresAt time.Time
+}
+
+// SignedURLStorage is a storage that can hand out signed URLs so that
+// clients download and upload objects directly from the backend
+type SignedURLStorage interface {
+	// SignedGetURL returns a URL to download an object
+	SignedGetURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error)
+
+	// SignedPutURL returns a URL to upload an object
+	SignedPutURL(ctx context.Context, key string, opts SignedURLOptions) (*SignedURL, error)
+}
+
+func signedURLExpiry(opts SignedURLOptions) (time.Duration, error) {
+	if opts.Expiry == 0 {
+		return defaultSignedURLExpiry, nil
+	}
+
+	if opts.Expiry < 0 || opts.Expiry > maxSignedURLExpiry {
+		return 0, fmt.Errorf("signed url expiry must be positive and at most %s", maxSignedURLExpiry)
+	}
+
+	return opts.Expiry, nil
+}
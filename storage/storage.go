@@ -2,7 +2,25 @@
 // general purpose storage system.
 package storage
 
-import "io"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist
+	ErrNotFound = errors.New("storage: object not found")
+
+	// ErrPreconditionFailed is returned when a conditional write is
+	// rejected because of the current state of the object
+	ErrPreconditionFailed = errors.New("storage: precondition failed")
+)
+
+// Default number of objects returned in a page by List
+const defaultListPageSize = 1000
 
 // Storage is a simple storage interface with read and write operations.
 // This interface should be extended to support more capable contracts
@@ -18,3 +36,181 @@ type StorageWriter interface {
 
 	Writer(key string) (io.WriteCloser, error)
 }
+
+// ContextStorageWriter is a storage writer with context aware variants of
+// its operations. Cancelling the context aborts in-flight operations
+type ContextStorageWriter interface {
+	StorageWriter
+
+	PutContext(ctx context.Context, key string, reader io.Reader) error
+	GetContext(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// WriterContext returns a writer bound to ctx. Cancelling ctx before
+	// Close aborts the write and the object is not created
+	WriterContext(ctx context.Context, key string) (io.WriteCloser, error)
+}
+
+// ObjectInfo is the metadata of a stored object
+type ObjectInfo struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+
+	// Empty when not known to the backend or not returned by List
+	ContentType     string
+	ContentEncoding string
+	CacheControl    string
+	Metadata        map[string]string
+
+	// Checksum of the content as reported by the backend, hex encoded.
+	// Empty when not available
+	Checksum string
+
+	// Algorithm of Checksum such as sha256, md5 or etag
+	ChecksumAlgorithm string
+
+	// Opaque version of the object used with PutOptions.IfMatch. Empty
+	// when not returned by List
+	Version string
+}
+
+// PutOptions are the metadata and preconditions of a write
+type PutOptions struct {
+	ContentType     string
+	ContentEncoding string
+	CacheControl    string
+
+	// Custom metadata stored with the object
+	Metadata map[string]string
+
+	// Write only when no object exists with the key, like If-None-Match: *
+	IfNoneMatch bool
+
+	// Write only when the current object has this version, like If-Match.
+	// The version is ObjectInfo.Version as returned by Stat
+	IfMatch string
+}
+
+func (o PutOptions) validate() error {
+	if o.IfNoneMatch && o.IfMatch != "" {
+		return fmt.Errorf("storage: if-none-match and if-match are mutually exclusive")
+	}
+
+	return nil
+}
+
+// ListOptions selects a page of objects to be listed
+type ListOptions struct {
+	// Only objects with keys starting with Prefix are listed
+	Prefix string
+
+	// Maximum number of objects in a page. Defaults to 1000
+	PageSize int
+
+	// NextPageToken of the previous page, empty for the first page
+	PageToken string
+}
+
+// ListResult is a page of objects ordered by key
+type ListResult struct {
+	Objects []ObjectInfo
+
+	// Token to fetch the next page, empty when there are no more objects
+	NextPageToken string
+}
+
+// OptionsStorageWriter is a storage writer that stores metadata with
+// objects and supports conditional writes. Writes rejected by a
+// precondition fail with ErrPreconditionFailed
+type OptionsStorageWriter interface {
+	ContextStorageWriter
+
+	PutWithOptions(ctx context.Context, key string, reader io.Reader, opts PutOptions) error
+
+	// WriterWithOptions returns a writer bound to ctx. Preconditions are
+	// evaluated when the writer is closed
+	WriterWithOptions(ctx context.Context, key string, opts PutOptions) (io.WriteCloser, error)
+}
+
+// ObjectStorage is a storage that supports enumerating and managing
+// stored objects in addition to reading and writing them
+type ObjectStorage interface {
+	ContextStorageWriter
+
+	// List returns a page of objects with keys starting with a prefix
+	List(ctx context.Context, opts ListOptions) (*ListResult, error)
+
+	// Stat returns the metadata of an object or ErrNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// Delete removes an object. Deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+
+	// Exists reports whether an object exists
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// contextReader fails reads once ctx is done so that copies from readers
+// that are not context aware can be cancelled
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.reader.Read(p)
+}
+
+// putContext writes through the context aware variant when the storage
+// supports it
+func putContext(ctx context.Context, s Storage, key string, reader io.Reader) error {
+	if cs, ok := s.(ContextStorageWriter); ok {
+		return cs.PutContext(ctx, key, reader)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.Put(key, &contextReader{ctx: ctx, reader: reader})
+}
+
+// getContext reads through the context aware variant when the storage
+// supports it
+func getContext(ctx context.Context, s Storage, key string) (io.ReadCloser, error) {
+	if cs, ok := s.(ContextStorageWriter); ok {
+		return cs.GetContext(ctx, key)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(key)
+}
+
+// writerContext opens a writer through the context aware variant when the
+// storage supports it
+func writerContext(ctx context.Context, s StorageWriter, key string) (io.WriteCloser, error) {
+	if cs, ok := s.(ContextStorageWriter); ok {
+		return cs.WriterContext(ctx, key)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.Writer(key)
+}
+
+func listPageSize(opts ListOptions) int {
+	if opts.PageSize <= 0 {
+		return defaultListPageSize
+	}
+
+	return opts.PageSize
+}
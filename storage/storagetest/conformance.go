@@ -0,0 +1,362 @@
+// Package storagetest contains a conformance suite that verifies a storage
+// driver follows the semantics shared by the drivers in package storage.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/safedep/dry/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty storage for a single test. Storages that also
+// implement storage.ContextStorageWriter or storage.ObjectStorage are
+// verified against those contracts as well
+type Factory func(t *testing.T) storage.StorageWriter
+
+// RunConformanceTests runs the conformance suite against storages created
+// by factory. The factory is called for every group of tests so that they
+// do not observe each other's objects
+func RunConformanceTests(t *testing.T, factory Factory) {
+	t.Run("StorageWriter", func(t *testing.T) {
+		testStorageWriter(t, factory)
+	})
+
+	t.Run("ContextStorageWriter", func(t *testing.T) {
+		s, ok := factory(t).(storage.ContextStorageWriter)
+		if !ok {
+			t.Skip("storage does not implement ContextStorageWriter")
+		}
+
+		testContextStorageWriter(t, s)
+	})
+
+	t.Run("OptionsStorageWriter", func(t *testing.T) {
+		s, ok := factory(t).(storage.OptionsStorageWriter)
+		if !ok {
+			t.Skip("storage does not implement OptionsStorageWriter")
+		}
+
+		testOptionsStorageWriter(t, s)
+	})
+
+	t.Run("ObjectStorage", func(t *testing.T) {
+		s, ok := factory(t).(storage.ObjectStorage)
+		if !ok {
+			t.Skip("storage does not implement ObjectStorage")
+		}
+
+		testObjectStorage(t, s)
+	})
+}
+
+func testStorageWriter(t *testing.T, factory Factory) {
+	t.Run("Get returns ErrNotFound for missing key", func(t *testing.T) {
+		s := factory(t)
+
+		_, err := s.Get("missing.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("Put and Get round trip", func(t *testing.T) {
+		s := factory(t)
+
+		for key, content := range map[string]string{"file.txt": "content", "empty.bin": ""} {
+			require.NoError(t, s.Put(key, strings.NewReader(content)))
+			assert.Equal(t, content, readKey(t, s, key))
+		}
+	})
+
+	t.Run("Put overwrites existing object", func(t *testing.T) {
+		s := factory(t)
+
+		require.NoError(t, s.Put("overwrite.txt", strings.NewReader("first and longer")))
+		require.NoError(t, s.Put("overwrite.txt", strings.NewReader("second")))
+
+		assert.Equal(t, "second", readKey(t, s, "overwrite.txt"))
+	})
+
+	t.Run("Nested keys", func(t *testing.T) {
+		s := factory(t)
+
+		require.NoError(t, s.Put("nested/a/b/c.txt", strings.NewReader("nested")))
+		assert.Equal(t, "nested", readKey(t, s, "nested/a/b/c.txt"))
+
+		// Leading and trailing slashes are ignored
+		assert.Equal(t, "nested", readKey(t, s, "/nested/a/b/c.txt/"))
+
+		// Intermediate path segments are not objects
+		_, err := s.Get("nested/a/b")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("Empty keys are rejected", func(t *testing.T) {
+		s := factory(t)
+
+		for _, key := range []string{"", "/", "///"} {
+			assert.Error(t, s.Put(key, strings.NewReader("x")))
+
+			_, err := s.Get(key)
+			assert.Error(t, err)
+
+			_, err = s.Writer(key)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("Writer stores object on Close", func(t *testing.T) {
+		s := factory(t)
+
+		w, err := s.Writer("writer.txt")
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("hello "))
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("world"))
+		require.NoError(t, err)
+
+		// Partially written objects are not visible
+		_, err = s.Get("writer.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		require.NoError(t, w.Close())
+		assert.Equal(t, "hello world", readKey(t, s, "writer.txt"))
+	})
+
+	t.Run("Writer fails after Close", func(t *testing.T) {
+		s := factory(t)
+
+		w, err := s.Writer("closed.txt")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		_, err = w.Write([]byte("late"))
+		assert.Error(t, err)
+		assert.Equal(t, "", readKey(t, s, "closed.txt"))
+	})
+}
+
+func testContextStorageWriter(t *testing.T, s storage.ContextStorageWriter) {
+	t.Run("Context variants round trip", func(t *testing.T) {
+		ctx := context.Background()
+		require.NoError(t, s.PutContext(ctx, "ctx/put.txt", strings.NewReader("put")))
+
+		w, err := s.WriterContext(ctx, "ctx/writer.txt")
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("writer"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		for key, content := range map[string]string{"ctx/put.txt": "put", "ctx/writer.txt": "writer"} {
+			reader, err := s.GetContext(ctx, key)
+			require.NoError(t, err)
+
+			got, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(got))
+			assert.NoError(t, reader.Close())
+		}
+	})
+
+	t.Run("Cancelled Put does not create object", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.Error(t, s.PutContext(ctx, "ctx/cancelled-put.txt", strings.NewReader("x")))
+
+		_, err := s.Get("ctx/cancelled-put.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("Cancelled Writer does not create object", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		w, err := s.WriterContext(ctx, "ctx/cancelled-writer.txt")
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte("partial"))
+		require.NoError(t, err)
+
+		cancel()
+		assert.Error(t, w.Close())
+
+		_, err = s.Get("ctx/cancelled-writer.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func testOptionsStorageWriter(t *testing.T, s storage.OptionsStorageWriter) {
+	ctx := context.Background()
+	objects, isObjectStorage := s.(storage.ObjectStorage)
+
+	t.Run("Metadata is stored with the object", func(t *testing.T) {
+		opts := storage.PutOptions{
+			ContentType:     "application/json",
+			ContentEncoding: "identity",
+			CacheControl:    "max-age=60",
+			Metadata:        map[string]string{"scanner": "vet"},
+		}
+
+		require.NoError(t, s.PutWithOptions(ctx, "options/meta.bin", strings.NewReader("meta"), opts))
+		assert.Equal(t, "meta", readKey(t, s, "options/meta.bin"))
+
+		if !isObjectStorage {
+			return
+		}
+
+		info, err := objects.Stat(ctx, "options/meta.bin")
+		require.NoError(t, err)
+
+		assert.Equal(t, opts.ContentType, info.ContentType)
+		assert.Equal(t, opts.ContentEncoding, info.ContentEncoding)
+		assert.Equal(t, opts.CacheControl, info.CacheControl)
+		assert.Equal(t, opts.Metadata, info.Metadata)
+
+		// Overwriting replaces the metadata
+		require.NoError(t, s.Put("options/meta.bin", strings.NewReader("plain")))
+
+		info, err = objects.Stat(ctx, "options/meta.bin")
+		require.NoError(t, err)
+
+		assert.Empty(t, info.ContentEncoding)
+		assert.Empty(t, info.CacheControl)
+		assert.Empty(t, info.Metadata)
+	})
+
+	t.Run("IfNoneMatch creates only absent objects", func(t *testing.T) {
+		opts := storage.PutOptions{IfNoneMatch: true}
+
+		require.NoError(t, s.PutWithOptions(ctx, "options/once.txt", strings.NewReader("first"), opts))
+
+		err := s.PutWithOptions(ctx, "options/once.txt", strings.NewReader("second"), opts)
+		assert.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+		w, err := s.WriterWithOptions(ctx, "options/once.txt", opts)
+		require.NoError(t, err)
+
+		_, _ = w.Write([]byte("third"))
+		assert.ErrorIs(t, w.Close(), storage.ErrPreconditionFailed)
+
+		assert.Equal(t, "first", readKey(t, s, "options/once.txt"))
+	})
+
+	t.Run("IfMatch replaces only the expected version", func(t *testing.T) {
+		if !isObjectStorage {
+			t.Skip("storage does not implement ObjectStorage")
+		}
+
+		err := s.PutWithOptions(ctx, "options/missing.txt", strings.NewReader("x"), storage.PutOptions{IfMatch: "1"})
+		assert.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+		require.NoError(t, s.Put("options/versioned.txt", strings.NewReader("v1")))
+
+		info, err := objects.Stat(ctx, "options/versioned.txt")
+		require.NoError(t, err)
+		require.NotEmpty(t, info.Version)
+
+		opts := storage.PutOptions{IfMatch: info.Version}
+		require.NoError(t, s.PutWithOptions(ctx, "options/versioned.txt", strings.NewReader("v2"), opts))
+
+		// The version changed with the previous write
+		err = s.PutWithOptions(ctx, "options/versioned.txt", strings.NewReader("v3"), opts)
+		assert.ErrorIs(t, err, storage.ErrPreconditionFailed)
+
+		assert.Equal(t, "v2", readKey(t, s, "options/versioned.txt"))
+	})
+
+	t.Run("Conflicting preconditions are rejected", func(t *testing.T) {
+		opts := storage.PutOptions{IfNoneMatch: true, IfMatch: "1"}
+		assert.Error(t, s.PutWithOptions(ctx, "options/conflict.txt", strings.NewReader("x"), opts))
+	})
+}
+
+func testObjectStorage(t *testing.T, s storage.ObjectStorage) {
+	ctx := context.Background()
+
+	keys := []string{"objects/a.txt", "objects/b/c.txt", "objects/b/d.txt", "other/e.txt"}
+	for _, key := range keys {
+		require.NoError(t, s.Put(key, strings.NewReader(key)))
+	}
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := s.Stat(ctx, "objects/a.txt")
+		require.NoError(t, err)
+
+		assert.Equal(t, "objects/a.txt", info.Key)
+		assert.Equal(t, int64(len("objects/a.txt")), info.Size)
+		assert.False(t, info.ModifiedAt.IsZero())
+
+		_, err = s.Stat(ctx, "objects/missing.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = s.Stat(ctx, "objects/b")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := s.Exists(ctx, "objects/b/c.txt")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = s.Exists(ctx, "objects/missing.txt")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("List pages through objects in key order", func(t *testing.T) {
+		var listed []string
+		opts := storage.ListOptions{Prefix: "objects/", PageSize: 2}
+
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, len(keys), "too many pages")
+
+			res, err := s.List(ctx, opts)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(res.Objects), 2)
+
+			for _, object := range res.Objects {
+				listed = append(listed, object.Key)
+			}
+
+			if res.NextPageToken == "" {
+				break
+			}
+
+			opts.PageToken = res.NextPageToken
+		}
+
+		assert.Equal(t, []string{"objects/a.txt", "objects/b/c.txt", "objects/b/d.txt"}, listed)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, "other/e.txt"))
+
+		_, err := s.Get("other/e.txt")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		// Deleting a missing object is not an error
+		assert.NoError(t, s.Delete(ctx, "other/e.txt"))
+	})
+}
+
+func readKey(t *testing.T, s storage.Storage, key string) string {
+	t.Helper()
+
+	reader, err := s.Get(key)
+	require.NoError(t, err)
+
+	defer func() { _ = reader.Close() }()
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, reader)
+	require.NoError(t, err)
+
+	return buf.String()
+}